@@ -0,0 +1,74 @@
+package web_request_readers
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/stretchr/goweb/context"
+)
+
+// Authorization schemes that ParseAuthorization knows the format of.
+const (
+	BasicScheme  = "Basic"
+	BearerScheme = "Bearer"
+)
+
+// Credentials are the parsed value of an Authorization header.
+type Credentials struct {
+	// Scheme is the authorization scheme.  BasicScheme and
+	// BearerScheme are always spelled that way, whatever case the
+	// client used; other schemes are as they were sent.
+	Scheme string
+
+	// Username and Password are the decoded user-id and password of
+	// Basic credentials.
+	Username string
+	Password string
+
+	// Token is the token of Bearer credentials, or the unparsed
+	// credentials of any other scheme.
+	Token string
+}
+
+// ParseAuthorization parses the value of an Authorization header,
+// e.g. "Basic dXNlcjpwYXNz" or "Bearer abc.def".  Basic credentials
+// are decoded from base64 and split at the first colon, as RFC 7617
+// says.  Malformed credentials are returned as InvalidFormat errors.
+// Fields with the source=auth tag option are read with
+// ParseAuthorization.
+func ParseAuthorization(header string) (Credentials, error) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	rest = strings.TrimSpace(rest)
+	if scheme == "" {
+		return Credentials{}, InvalidFormat{Format: "Authorization header", Value: header}
+	}
+	switch {
+	case strings.EqualFold(scheme, BasicScheme):
+		decoded, err := base64.StdEncoding.DecodeString(rest)
+		if err != nil {
+			return Credentials{}, InvalidFormat{Format: "Basic credentials", Value: rest, Err: err}
+		}
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return Credentials{}, InvalidFormat{Format: "Basic credentials", Value: rest}
+		}
+		return Credentials{Scheme: BasicScheme, Username: username, Password: password}, nil
+	case strings.EqualFold(scheme, BearerScheme):
+		if rest == "" {
+			return Credentials{}, InvalidFormat{Format: "Bearer token", Value: rest}
+		}
+		return Credentials{Scheme: BearerScheme, Token: rest}, nil
+	}
+	return Credentials{Scheme: scheme, Token: rest}, nil
+}
+
+// RequestCredentials parses the Authorization header of a request.
+// The second return value is false if the request doesn't have one.
+func RequestCredentials(ctx context.Context) (Credentials, bool, error) {
+	header := ctx.HttpRequest().Header.Get("Authorization")
+	if header == "" {
+		return Credentials{}, false, nil
+	}
+	credentials, err := ParseAuthorization(header)
+	return credentials, err == nil, err
+}
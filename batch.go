@@ -0,0 +1,144 @@
+package web_request_readers
+
+import (
+	"fmt"
+
+	"github.com/stretchr/goweb/context"
+	"github.com/stretchr/objx"
+)
+
+// A ModelFactory returns a new model (a pointer to a struct) for the
+// data of a bulk operation with the given method, e.g. &User{} for
+// "create".  It may return nil for methods that take no data, such as
+// "delete", and returns an error for methods the endpoint doesn't
+// support.
+type ModelFactory func(method string) (interface{}, error)
+
+// An Operation is a single entry of a bulk request envelope.
+type Operation struct {
+	// Index is the position of the operation in the envelope.
+	Index int
+
+	// Method is the operation's "method", e.g. "create".
+	Method string
+
+	// Data is the operation's "data" object, as it was sent.
+	Data objx.Map
+
+	// Model is the model that the ModelFactory returned for Method,
+	// with Data unmarshalled to it.  It is nil if the operation had
+	// no method or the factory returned nil or an error.
+	Model interface{}
+
+	// Err is the error for this operation alone, if there was one,
+	// so that endpoints that allow partial success can skip it.
+	Err error
+}
+
+// ParseBatch parses a bulk request envelope, e.g.
+//
+//	{"operations": [
+//		{"method": "create", "data": {"name": "Ada"}},
+//		{"method": "delete", "data": {"id": 7}}
+//	]}
+//
+// and unmarshals each operation's data to a model from factory, just
+// as UnmarshalRequestParams would.  Every operation is returned, even
+// if some have errors.
+//
+// Errors name the operation they came from by its index, e.g.
+// "operations[2].data.price".  If any operation has an error other
+// than missing fields, the returned error is of type FieldErrors and
+// lists every such error; otherwise, missing fields from all
+// operations are returned together as MissingFields.
+func ParseBatch(ctx context.Context, factory ModelFactory) ([]Operation, error) {
+	return DefaultUnmarshaler.ParseBatch(ctx, factory)
+}
+
+// ParseBatch parses a bulk request envelope using the unmarshaler's
+// options.  See the package-level ParseBatch for details.
+func (unmarshaler *Unmarshaler) ParseBatch(ctx context.Context, factory ModelFactory) ([]Operation, error) {
+	params, err := ParseParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rawOps, ok := params["operations"]
+	if !ok {
+		var missing MissingFields
+		missing.AddMissingField("operations")
+		return nil, missing
+	}
+	elems, ok := rawOps.([]interface{})
+	if !ok {
+		return nil, FieldErrors{{
+			Field:   "operations",
+			Code:    "type",
+			Message: "Parameter operations must be an array of operations",
+		}}
+	}
+
+	var collected elementErrors
+	ops := make([]Operation, len(elems))
+	for index, elem := range elems {
+		op := &ops[index]
+		op.Index = index
+		op.Err = unmarshaler.parseOperation(ctx, op, elem, factory)
+		collected.add(fmt.Sprintf("operations[%d]", index), op.Err)
+	}
+	return ops, collected.err()
+}
+
+// parseOperation fills in op from one element of the operations
+// array, returning the operation's error.
+func (unmarshaler *Unmarshaler) parseOperation(ctx context.Context, op *Operation, elem interface{}, factory ModelFactory) error {
+	key := fmt.Sprintf("operations[%d]", op.Index)
+	params, ok := asParams(elem)
+	if !ok {
+		return FieldError{
+			Field:   key,
+			Code:    "type",
+			Message: fmt.Sprintf("Operation %d is not an object", op.Index),
+		}
+	}
+	if rawMethod, ok := params["method"]; !ok || rawMethod == nil || rawMethod == "" {
+		var missing MissingFields
+		missing.AddMissingField(key + ".method")
+		return missing
+	}
+	method, ok := params["method"].(string)
+	if !ok {
+		return FieldError{
+			Field:   key + ".method",
+			Code:    "type",
+			Message: fmt.Sprintf("The method of operation %d is not a string", op.Index),
+		}
+	}
+	op.Method = method
+	if rawData, ok := params["data"]; ok && rawData != nil {
+		if op.Data, ok = asParams(rawData); !ok {
+			return FieldError{
+				Field:   key + ".data",
+				Code:    "type",
+				Message: fmt.Sprintf("The data of operation %d is not an object", op.Index),
+			}
+		}
+	}
+
+	model, err := factory(method)
+	if err != nil {
+		return FieldError{Field: key + ".method", Code: "invalid", Message: err.Error(), Err: err}
+	}
+	if model == nil {
+		return nil
+	}
+	data := op.Data
+	if data == nil {
+		data = objx.Map{}
+	}
+	op.Model = model
+	state := unmarshaler.newState(ctx, data)
+	defer state.release()
+	state.keyPath = key + ".data."
+	state.fieldPath = key + ".data."
+	return unmarshaler.unmarshal(state, model)
+}
@@ -0,0 +1,88 @@
+package web_request_readers
+
+import (
+	"fmt"
+
+	"github.com/stretchr/objx"
+)
+
+// BatchLimits are the server's rules for the batching hints of a bulk
+// request.  A zero maximum leaves the hint uncapped.
+type BatchLimits struct {
+	// DefaultBatchSize and DefaultConcurrency are used when the
+	// request doesn't give a hint.  Zero defaults to the maximum, or
+	// to 1 if there is no maximum.
+	DefaultBatchSize   int
+	DefaultConcurrency int
+
+	// MaxBatchSize and MaxConcurrency are the most that clients may
+	// ask for.  Larger hints are clamped to them.
+	MaxBatchSize   int
+	MaxConcurrency int
+}
+
+// BatchHints describes how a bulk request asked to be processed.
+type BatchHints struct {
+	// BatchSize is the number of items to process at a time.
+	BatchSize int
+
+	// Concurrency is the number of batches to process at once.
+	Concurrency int
+
+	// Warnings describe the hints that were clamped to a maximum,
+	// e.g. for a Warning header or a "warnings" field in the
+	// response, so clients learn that they didn't get what they
+	// asked for.
+	Warnings []string
+}
+
+// ParseBatchHints reads "batch_size" and "concurrency" from a set of
+// parameters and returns the BatchHints they describe, within limits.
+// The values may be of any type that ParsePagination accepts.  Hints
+// above their maximum are clamped to it, with a warning; a hint that
+// isn't a whole number, or that is below 1, results in an InvalidParam
+// error.
+func ParseBatchHints(params objx.Map, limits BatchLimits) (*BatchHints, error) {
+	hints := &BatchHints{}
+	var err error
+	hints.BatchSize, err = hints.clamp(params, "batch_size", limits.DefaultBatchSize, limits.MaxBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	hints.Concurrency, err = hints.clamp(params, "concurrency", limits.DefaultConcurrency, limits.MaxConcurrency)
+	if err != nil {
+		return nil, err
+	}
+	return hints, nil
+}
+
+// clamp reads the hint called name from params, falling back to
+// defaultValue, and clamps it to max, adding a warning if the client
+// asked for more.
+func (hints *BatchHints) clamp(params objx.Map, name string, defaultValue, max int) (int, error) {
+	if defaultValue < 1 {
+		defaultValue = 1
+		if max > 0 {
+			defaultValue = max
+		}
+	}
+	if max > 0 && defaultValue > max {
+		defaultValue = max
+	}
+	rawVal, ok := params[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	value, err := intParam(name, rawVal)
+	if err != nil {
+		return 0, err
+	}
+	if value < 1 {
+		return 0, InvalidParam{Param: name, Message: fmt.Sprintf("Parameter %s must be at least 1", name)}
+	}
+	if max > 0 && value > max {
+		hints.Warnings = append(hints.Warnings, fmt.Sprintf("Parameter %s was reduced from %d to the maximum of %d", name, value, max))
+		return max, nil
+	}
+	return value, nil
+}
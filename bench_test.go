@@ -0,0 +1,106 @@
+package web_request_readers
+
+import (
+	"testing"
+
+	"github.com/stretchr/objx"
+)
+
+type benchFlat struct {
+	Name   string
+	Age    int
+	Score  float64
+	Active bool
+}
+
+type benchAddress struct {
+	Street string `request:"street"`
+	City   string `request:"city"`
+}
+
+type benchNested struct {
+	Name    string       `request:"name"`
+	Address benchAddress `request:"address"`
+	Tags    []string     `request:"tags"`
+}
+
+type benchEmail string
+
+func (email *benchEmail) Receive(value interface{}) error {
+	*email = benchEmail(value.(string))
+	return nil
+}
+
+type benchReceivers struct {
+	Name  string      `request:"name"`
+	Email *benchEmail `request:"email"`
+}
+
+func BenchmarkUnmarshalParamsFlat(b *testing.B) {
+	params := objx.Map{"name": "bob", "age": 42.0, "score": 2.5, "active": true}
+	var target benchFlat
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		target = benchFlat{}
+		if err := UnmarshalParams(params, &target); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalParamsNested(b *testing.B) {
+	params := objx.Map{
+		"name":    "bob",
+		"address": objx.Map{"street": "1 Main St", "city": "Springfield"},
+		"tags":    []interface{}{"a", "b", "c"},
+	}
+	var target benchNested
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		target = benchNested{}
+		if err := UnmarshalParams(params, &target); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalParamsReceivers(b *testing.B) {
+	params := objx.Map{"name": "bob", "email": "bob@example.com"}
+	var target benchReceivers
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		target = benchReceivers{}
+		if err := UnmarshalParams(params, &target); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalParamsMissingFields(b *testing.B) {
+	params := objx.Map{"name": "bob"}
+	var target benchFlat
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		target = benchFlat{}
+		if err := UnmarshalParams(params, &target); err == nil {
+			b.Fatal("expected missing fields")
+		}
+	}
+}
+
+func TestUnmarshalParamsFlatAllocs(t *testing.T) {
+	params := objx.Map{"name": "bob", "age": 42.0, "score": 2.5, "active": true}
+	var target benchFlat
+	allocs := testing.AllocsPerRun(100, func() {
+		target = benchFlat{}
+		if err := UnmarshalParams(params, &target); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("UnmarshalParams of a flat struct made %v allocations, want 0", allocs)
+	}
+	if target != (benchFlat{Name: "bob", Age: 42, Score: 2.5, Active: true}) {
+		t.Errorf("unexpected result %+v", target)
+	}
+}
@@ -0,0 +1,50 @@
+package web_request_readers
+
+import (
+	"github.com/stretchr/goweb/context"
+)
+
+// A BindCheck is a check that Bind runs against a request before its
+// params are unmarshalled.  Returning an error stops the bind.
+type BindCheck func(ctx context.Context) error
+
+var bindChecks = []BindCheck{CheckClientVersion}
+
+// AddBindCheck adds a check to the list of checks that Bind runs
+// before unmarshalling a request.  Checks are run in the order they
+// were added; CheckClientVersion is always first.
+func AddBindCheck(check BindCheck) {
+	bindChecks = append(bindChecks, check)
+}
+
+// Bind runs all bind checks against a request, then parses its params
+// and unmarshals them to target.  Errors are returned exactly as the
+// check, ParseAssignments, ParseParams, or UnmarshalParams returned
+// them, so the usual type tests (e.g. for MissingFields or
+// UpgradeRequired) still work.
+//
+// If any experiments are registered, the request's assignments are
+// parsed (and cached) with ParseAssignments after the checks, so an
+// InvalidAssignment stops the bind, and fields with the "experiment"
+// source receive them.
+//
+// If target is a ProfiledModel with a deprecated profile, deprecation
+// headers are added to ResponseHeaders.
+func Bind(ctx context.Context, target interface{}) error {
+	for _, check := range bindChecks {
+		if err := check(ctx); err != nil {
+			return err
+		}
+	}
+	if len(experiments) > 0 {
+		if _, err := ParseAssignments(ctx); err != nil {
+			return err
+		}
+	}
+	params, err := ParseParams(ctx)
+	if err != nil {
+		return err
+	}
+	addDeprecationHeaders(ctx, target)
+	return UnmarshalRequestParams(ctx, params, target)
+}
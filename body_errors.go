@@ -0,0 +1,67 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// WrongBodyShape is the error type returned by ParseParams when the
+// request body parsed successfully, but isn't an object.  Handlers
+// that can deal with other shapes (e.g. an array of models for
+// UnmarshalSlice) can use Value instead of parsing the body again.
+type WrongBodyShape struct {
+	// Kind is the JSON kind of the body: "array", "string",
+	// "number", or "boolean".
+	Kind string
+
+	// Value is the parsed body, as ParseBody returned it.
+	Value interface{}
+}
+
+// Error returns the error message for a WrongBodyShape error.
+func (err WrongBodyShape) Error() string {
+	return fmt.Sprintf("Cannot use %s body as params", err.Kind)
+}
+
+// bodyKind returns the JSON kind of a parsed body.
+func bodyKind(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	}
+	if isNumber(value) {
+		return "number"
+	}
+	return reflect.TypeOf(value).String()
+}
+
+// SupportedMediaTypes are the body content types that ParseBody knows
+// how to decode.
+var SupportedMediaTypes = []string{
+	"application/json",
+	"text/json",
+	"application/x-www-form-urlencoded",
+	"multipart/form-data",
+	MergePatchType,
+	JSONPatchType,
+}
+
+// UnsupportedMediaType is the error type returned by ParseBody, when
+// Config.StrictContentTypes is set, for a body with a Content-Type it
+// doesn't know how to decode.  It is suitable for a 415 Unsupported
+// Media Type response.
+type UnsupportedMediaType struct {
+	MimeType  string
+	Supported []string
+}
+
+// Error returns the error message for an UnsupportedMediaType error.
+func (err UnsupportedMediaType) Error() string {
+	return fmt.Sprintf("Unsupported content type %s; supported types are: %s",
+		err.MimeType, strings.Join(err.Supported, ", "))
+}
@@ -0,0 +1,59 @@
+package web_request_readers
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// base64Encodings are tried in order when decoding a string for a
+// []byte field.
+var base64Encodings = []*base64.Encoding{
+	base64.StdEncoding,
+	base64.URLEncoding,
+	base64.RawStdEncoding,
+	base64.RawURLEncoding,
+}
+
+// isBytesType returns whether t is []byte, or a named type based on
+// it.
+func isBytesType(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// setBytes sets a []byte field from a string, which is decoded as
+// base64 (in either the standard or URL-safe alphabet, with or
+// without padding) unless raw is true.  JSON has no way to send raw
+// bytes, so base64 is what clients (and encoding/json) use for them.
+// Fields that want the string's bytes as they are use the "raw" tag
+// option, e.g.
+//
+//	request:"signature,raw"
+func setBytes(target reflect.Value, value string, raw bool) error {
+	if raw {
+		target.SetBytes([]byte(value))
+		return nil
+	}
+	// Line breaks are allowed, as MIME encoders wrap long values.
+	value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+	decoded, err := decodeBase64(value)
+	if err != nil {
+		return errors.New("Cannot decode value as base64")
+	}
+	target.SetBytes(decoded)
+	return nil
+}
+
+// decodeBase64 decodes value with the first of base64Encodings that
+// accepts it.
+func decodeBase64(value string) ([]byte, error) {
+	var err error
+	for _, encoding := range base64Encodings {
+		var decoded []byte
+		if decoded, err = encoding.DecodeString(value); err == nil {
+			return decoded, nil
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,167 @@
+package web_request_readers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stretchr/goweb/context"
+)
+
+const clientInfoDataKey = "client_info"
+
+var (
+	// ClientVersionHeader is the request header that client versions
+	// are read from.
+	ClientVersionHeader = "X-Client-Version"
+
+	// ClientPlatformHeader is the request header that client
+	// platforms (e.g. "ios", "android", "web") are read from.
+	ClientPlatformHeader = "X-Client-Platform"
+)
+
+// A Version is a dotted version number, e.g. 2.10.1, stored as its
+// numeric components.
+type Version []int
+
+// ParseVersion parses a dotted version number.  A leading "v" is
+// allowed, and anything after a "-" or "+" (pre-release or build
+// metadata) is ignored.
+func ParseVersion(raw string) (Version, error) {
+	raw = strings.TrimPrefix(strings.TrimSpace(raw), "v")
+	if idx := strings.IndexAny(raw, "-+"); idx != -1 {
+		raw = raw[:idx]
+	}
+	if raw == "" {
+		return nil, errors.New("Cannot parse empty version")
+	}
+	parts := strings.Split(raw, ".")
+	version := make(Version, 0, len(parts))
+	for _, part := range parts {
+		num, err := strconv.Atoi(part)
+		if err != nil || num < 0 {
+			return nil, fmt.Errorf("Invalid version component %q", part)
+		}
+		version = append(version, num)
+	}
+	return version, nil
+}
+
+// Compare returns -1, 0, or 1 depending on whether version is lower
+// than, equal to, or higher than other.  Missing components are
+// treated as zero, so 1.2 and 1.2.0 are equal.
+func (version Version) Compare(other Version) int {
+	for i := 0; i < len(version) || i < len(other); i++ {
+		var a, b int
+		if i < len(version) {
+			a = version[i]
+		}
+		if i < len(other) {
+			b = other[i]
+		}
+		if a < b {
+			return -1
+		}
+		if a > b {
+			return 1
+		}
+	}
+	return 0
+}
+
+// String returns the dotted representation of version.
+func (version Version) String() string {
+	parts := make([]string, len(version))
+	for i, num := range version {
+		parts[i] = strconv.Itoa(num)
+	}
+	return strings.Join(parts, ".")
+}
+
+// ClientInfo stores the platform and version that a client reported
+// in its request headers.
+type ClientInfo struct {
+	// Platform is the lower-cased value of ClientPlatformHeader.
+	Platform string
+
+	// Version is the parsed value of ClientVersionHeader, or nil
+	// if the client didn't send a version.
+	Version Version
+}
+
+// ParseClientInfo reads the client platform and version from a
+// request's headers.  The result is cached in ctx.Data().
+func ParseClientInfo(ctx context.Context) (*ClientInfo, error) {
+	if info, ok := ctx.Data()[clientInfoDataKey].(*ClientInfo); ok {
+		return info, nil
+	}
+	header := ctx.HttpRequest().Header
+	info := &ClientInfo{
+		Platform: strings.ToLower(strings.TrimSpace(header.Get(ClientPlatformHeader))),
+	}
+	if rawVersion := header.Get(ClientVersionHeader); rawVersion != "" {
+		version, err := ParseVersion(rawVersion)
+		if err != nil {
+			return nil, err
+		}
+		info.Version = version
+	}
+	ctx.Data().Set(clientInfoDataKey, info)
+	return info, nil
+}
+
+var minimumVersions = make(map[string]Version)
+
+// SetMinimumClientVersion sets the lowest client version that will be
+// accepted for platform.  An empty platform applies to any client
+// that doesn't have its own minimum.  An empty version removes the
+// minimum for platform.
+func SetMinimumClientVersion(platform, version string) error {
+	platform = strings.ToLower(platform)
+	if version == "" {
+		delete(minimumVersions, platform)
+		return nil
+	}
+	parsed, err := ParseVersion(version)
+	if err != nil {
+		return err
+	}
+	minimumVersions[platform] = parsed
+	return nil
+}
+
+// UpgradeRequired is the error type returned when a client's version
+// is lower than the minimum version set for its platform.
+type UpgradeRequired struct {
+	Platform string
+	Version  Version
+	Minimum  Version
+}
+
+// Error returns the error message for an UpgradeRequired error.
+func (err UpgradeRequired) Error() string {
+	return fmt.Sprintf("Client version %s is below the minimum supported version %s", err.Version, err.Minimum)
+}
+
+// CheckClientVersion returns an UpgradeRequired error if the
+// requesting client's version is lower than the minimum set for its
+// platform.  Clients that don't send a version header are allowed
+// through, since there's nothing to compare.
+func CheckClientVersion(ctx context.Context) error {
+	info, err := ParseClientInfo(ctx)
+	if err != nil {
+		return err
+	}
+	if info.Version == nil {
+		return nil
+	}
+	minimum, ok := minimumVersions[info.Platform]
+	if !ok {
+		minimum, ok = minimumVersions[""]
+	}
+	if ok && info.Version.Compare(minimum) < 0 {
+		return UpgradeRequired{Platform: info.Platform, Version: info.Version, Minimum: minimum}
+	}
+	return nil
+}
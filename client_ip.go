@@ -0,0 +1,116 @@
+package web_request_readers
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIPConfig describes the proxies in front of a server, for
+// ClientIP.
+type ClientIPConfig struct {
+	// TrustedProxies are the addresses of the proxies.
+	TrustedProxies []netip.Prefix
+
+	// Header is the one header that the proxies record client
+	// addresses in: "Forwarded" (RFC 7239), "X-Real-IP" (a single
+	// address), or "X-Forwarded-For" or any other header holding a
+	// comma-separated list of addresses.  No other header is read,
+	// since a client can send any header that the proxies don't
+	// overwrite.  If Header is empty, headers are ignored.
+	Header string
+}
+
+// ClientIP returns the address of the client that sent r, looking
+// through the proxies described by config.
+//
+// The request's RemoteAddr is the client unless it is a trusted
+// proxy.  If it is, the addresses in config.Header are walked from the
+// right (the most recent hop) to the left, skipping trusted proxies,
+// and the first untrusted address is the client, since every address
+// to its left could have been made up by the client itself.  If a hop
+// can't be parsed (e.g. "for=unknown"), the last trusted proxy is
+// returned, and if every hop is trusted, the leftmost one is.
+//
+// With no trusted proxies or no header, ClientIP returns RemoteAddr,
+// which is the only safe choice for servers that clients reach
+// directly.  The zero netip.Addr is returned if RemoteAddr isn't an IP
+// address.
+func ClientIP(r *http.Request, config ClientIPConfig) netip.Addr {
+	client, ok := parseHopAddr(r.RemoteAddr)
+	if !ok || config.Header == "" || !trustedIP(client, config.TrustedProxies) {
+		return client
+	}
+	for _, hop := range forwardedHops(r.Header, config.Header) {
+		addr, ok := parseHopAddr(hop)
+		if !ok {
+			return client
+		}
+		client = addr
+		if !trustedIP(client, config.TrustedProxies) {
+			return client
+		}
+	}
+	return client
+}
+
+// forwardedHops returns the client addresses that proxies recorded in
+// the name header, from the most recent hop to the oldest.
+func forwardedHops(header http.Header, name string) []string {
+	values := header.Values(name)
+	if len(values) == 0 {
+		return nil
+	}
+	var hops []string
+	switch http.CanonicalHeaderKey(name) {
+	case "Forwarded":
+		for _, element := range splitQuoted(strings.Join(values, ","), ',') {
+			var hop string
+			for _, pair := range splitQuoted(element, ';') {
+				key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+				if strings.EqualFold(key, "for") {
+					hop = unquote(value)
+				}
+			}
+			hops = append(hops, hop)
+		}
+	case "X-Real-Ip":
+		// Proxies set X-Real-IP rather than appending to it, so
+		// only the last value can be theirs.
+		hops = []string{values[len(values)-1]}
+	default:
+		hops = strings.Split(strings.Join(values, ","), ",")
+	}
+	for i, j := 0, len(hops)-1; i < j; i, j = i+1, j-1 {
+		hops[i], hops[j] = hops[j], hops[i]
+	}
+	return hops
+}
+
+// parseHopAddr parses an address with or without a port, e.g.
+// "192.0.2.1", "192.0.2.1:4711", "2001:db8::1", or
+// "[2001:db8::1]:4711".  IPv4 addresses mapped to IPv6 are unmapped,
+// so they match IPv4 prefixes.
+func parseHopAddr(value string) (netip.Addr, bool) {
+	value = strings.TrimSpace(value)
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// trustedIP returns whether addr is in one of the trusted prefixes.
+func trustedIP(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
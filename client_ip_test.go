@@ -0,0 +1,104 @@
+package web_request_readers
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     string
+		headers    map[string][]string
+		want       string
+	}{
+		{
+			name:       "untrusted remote ignores headers",
+			remoteAddr: "203.0.113.5:1234",
+			header:     "X-Forwarded-For",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.2.3.4"}},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "no header configured ignores headers",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"203.0.113.9"}},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "forged Forwarded is ignored behind an X-Forwarded-For proxy",
+			remoteAddr: "10.0.0.1:1234",
+			header:     "X-Forwarded-For",
+			headers: map[string][]string{
+				"X-Forwarded-For": {"203.0.113.9"},
+				"Forwarded":       {"for=1.2.3.4"},
+			},
+			want: "203.0.113.9",
+		},
+		{
+			name:       "forged X-Forwarded-For is ignored behind a Forwarded proxy",
+			remoteAddr: "10.0.0.1:1234",
+			header:     "Forwarded",
+			headers: map[string][]string{
+				"Forwarded":       {`for="[2001:db8::9]:4711";proto=https`},
+				"X-Forwarded-For": {"1.2.3.4"},
+			},
+			want: "2001:db8::9",
+		},
+		{
+			name:       "forged X-Real-IP is ignored behind an X-Forwarded-For proxy",
+			remoteAddr: "10.0.0.1:1234",
+			header:     "X-Forwarded-For",
+			headers: map[string][]string{
+				"X-Forwarded-For": {"203.0.113.9"},
+				"X-Real-Ip":       {"1.2.3.4"},
+			},
+			want: "203.0.113.9",
+		},
+		{
+			name:       "missing configured header falls back to remote",
+			remoteAddr: "10.0.0.1:1234",
+			header:     "X-Forwarded-For",
+			headers: map[string][]string{
+				"Forwarded": {"for=1.2.3.4"},
+				"X-Real-Ip": {"1.2.3.4"},
+			},
+			want: "10.0.0.1",
+		},
+		{
+			name:       "client-supplied hops left of the proxy's are ignored",
+			remoteAddr: "10.0.0.1:1234",
+			header:     "X-Forwarded-For",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.2.3.4, 203.0.113.9", "10.0.0.2"}},
+			want:       "203.0.113.9",
+		},
+		{
+			name:       "unparseable hop returns the last trusted proxy",
+			remoteAddr: "10.0.0.1:1234",
+			header:     "Forwarded",
+			headers:    map[string][]string{"Forwarded": {"for=1.2.3.4, for=unknown"}},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "only the last X-Real-IP is used",
+			remoteAddr: "10.0.0.1:1234",
+			header:     "X-Real-IP",
+			headers:    map[string][]string{"X-Real-Ip": {"1.2.3.4", "203.0.113.9"}},
+			want:       "203.0.113.9",
+		},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.RemoteAddr = test.remoteAddr
+		for name, values := range test.headers {
+			request.Header[name] = values
+		}
+		got := ClientIP(request, ClientIPConfig{TrustedProxies: trusted, Header: test.header})
+		if got.String() != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
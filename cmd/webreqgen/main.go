@@ -0,0 +1,347 @@
+// Command webreqgen generates static unmarshallers for request
+// models, so that hot endpoints don't pay for UnmarshalParams'
+// reflection on every request.  It is meant to be run with go
+// generate:
+//
+//	//go:generate webreqgen -type=CreateUser,UpdateUser
+//
+// For each named struct type, webreqgen writes an Unmarshal method,
+// which makes the type an Unmarshaller, so UnmarshalParams (and Bind)
+// will hand the params straight to it.  Keys are resolved from the
+// request, response, and db tags at generation time, exactly as
+// UnmarshalParams would resolve them, and fields of the basic types
+// (string, bool, and the numeric types) are converted without
+// reflection.  Any other field type is converted with
+// web_request_readers.UnmarshalValue.
+//
+// As at run time, Optional fields aren't required unless they are
+// tagged "required".
+//
+// The generated method can't see the Unmarshaler or the request
+// Options that call it, so keys are resolved with the -tag and -keys
+// flags instead of Options.TagName and Unmarshaler.KeyMatcher.  Types
+// that are bound with other settings must not be generated, and key
+// matchers other than the built-in LowerKeys, ExactKeys, SnakeKeys,
+// and CamelKeys (e.g. CaseInsensitiveKeys) can't be generated at all.
+//
+// Types that use features the generator doesn't understand, such as
+// embedded structs or tag options other than "optional" and
+// "required", are skipped with a warning and keep using the
+// reflective path.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	web_request_readers "github.com/Radiobox/web_request_readers"
+)
+
+const importPath = "github.com/Radiobox/web_request_readers"
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of struct type names; required")
+	output    = flag.String("output", "", "output file name; default <file>_webreq.go")
+	tagName   = flag.String("tag", web_request_readers.DefaultTagName, "struct tag that keys and options are read from, as Options.TagName")
+	keyStyle  = flag.String("keys", "lower", "keys of untagged fields: lower, exact, snake, or camel, as Unmarshaler.KeyMatcher")
+)
+
+// keyStyles are the key matchers that -keys can name, each as the
+// single key that it matches.
+var keyStyles = map[string]func(fieldName string) string{
+	"lower": strings.ToLower,
+	"exact": func(fieldName string) string { return fieldName },
+	"snake": web_request_readers.SnakeKey,
+	"camel": web_request_readers.CamelKey,
+}
+
+// field is a struct field that the generated code will set.
+type field struct {
+	goName   string
+	key      string
+	typeName string
+
+	// required is "true" or "false" for fields with an explicit
+	// required or optional option, and "" for fields that follow
+	// web_request_readers.CurrentConfig().DefaultRequired.
+	required string
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("webreqgen: ")
+	flag.Parse()
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	source := os.Getenv("GOFILE")
+	if flag.NArg() > 0 {
+		source = flag.Arg(0)
+	}
+	if source == "" {
+		log.Fatal("no source file; run from go generate or pass a file name")
+	}
+	untaggedKey, ok := keyStyles[*keyStyle]
+	if !ok {
+		log.Fatalf("unsupported -keys %q; only lower, exact, snake, and camel keys can be generated", *keyStyle)
+	}
+
+	fileSet := token.NewFileSet()
+	file, err := parser.ParseFile(fileSet, source, nil, parser.ParseComments)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var body bytes.Buffer
+	generated := 0
+	for _, name := range strings.Split(*typeNames, ",") {
+		name = strings.TrimSpace(name)
+		structType := findStruct(file, name)
+		if structType == nil {
+			log.Fatalf("no struct type %s in %s", name, source)
+		}
+		fields, err := structFields(structType, untaggedKey)
+		if err != nil {
+			log.Printf("skipping %s: %s", name, err)
+			continue
+		}
+		writeUnmarshal(&body, name, fields)
+		generated++
+	}
+	if generated == 0 {
+		log.Fatal("no types to generate")
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by webreqgen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", file.Name.Name)
+	fmt.Fprintf(&out, "import (\n\t\"sort\"\n\n\t\"github.com/stretchr/objx\"\n\n\tweb_request_readers %q\n)\n\n", importPath)
+	out.Write(body.Bytes())
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		log.Fatalf("formatting generated code: %s", err)
+	}
+
+	outName := *output
+	if outName == "" {
+		outName = strings.TrimSuffix(filepath.Base(source), ".go") + "_webreq.go"
+		outName = filepath.Join(filepath.Dir(source), outName)
+	}
+	if err := os.WriteFile(outName, formatted, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// findStruct finds the declaration of the named struct type.
+func findStruct(file *ast.File, name string) *ast.StructType {
+	var found *ast.StructType
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.TypeSpec)
+		if !ok || spec.Name.Name != name {
+			return found == nil
+		}
+		found, _ = spec.Type.(*ast.StructType)
+		return false
+	})
+	return found
+}
+
+// structFields resolves the key and options of each field, the same
+// way UnmarshalParams does, with untaggedKey as the key matcher.
+func structFields(structType *ast.StructType, untaggedKey func(string) string) ([]field, error) {
+	var fields []field
+	for _, astField := range structType.Fields.List {
+		if len(astField.Names) == 0 {
+			return nil, fmt.Errorf("embedded fields are not supported")
+		}
+		var tag reflect.StructTag
+		if astField.Tag != nil {
+			unquoted, err := strconv.Unquote(astField.Tag.Value)
+			if err != nil {
+				return nil, err
+			}
+			tag = reflect.StructTag(unquoted)
+		}
+		for _, ident := range astField.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			key, args, tagged := nameAndArgs(ident.Name, tag)
+			if key == "-" {
+				continue
+			}
+			if !tagged {
+				key = untaggedKey(ident.Name)
+			}
+			f := field{goName: ident.Name, key: key, typeName: exprString(astField.Type)}
+			if isOptionalExpr(astField.Type) {
+				f.required = "false"
+			}
+			for _, arg := range args {
+				switch arg {
+				case "optional":
+					f.required = "false"
+				case "required":
+					f.required = "true"
+				default:
+					return nil, fmt.Errorf("field %s uses the unsupported option %q", ident.Name, arg)
+				}
+			}
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+// nameAndArgs mirrors web_request_readers.NameAndArgs, reading the
+// -tag tag, for a field that hasn't been compiled yet.  The last
+// return value is false if no tag named the key.
+func nameAndArgs(goName string, tag reflect.StructTag) (string, []string, bool) {
+	var args []string
+	parts := strings.Split(tag.Get(*tagName), ",")
+	for _, arg := range parts[1:] {
+		if arg != "" {
+			args = append(args, arg)
+		}
+	}
+	if name := parts[0]; name != "" {
+		return name, args, true
+	}
+	if name := tag.Get("response"); name != "" {
+		return name, args, true
+	}
+	if name := tag.Get("db"); name != "" && name != "-" {
+		return name, args, true
+	}
+	return strings.ToLower(goName), args, false
+}
+
+// isOptionalExpr returns whether a field's type is an Optional, or a
+// pointer to one, which UnmarshalParams never reports as missing
+// unless it is tagged "required".
+func isOptionalExpr(expr ast.Expr) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	index, ok := expr.(*ast.IndexExpr)
+	if !ok {
+		return false
+	}
+	switch name := index.X.(type) {
+	case *ast.Ident:
+		return name.Name == "Optional"
+	case *ast.SelectorExpr:
+		return name.Sel.Name == "Optional"
+	}
+	return false
+}
+
+// exprString returns the source form of a field's type.
+func exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	format.Node(&buf, token.NewFileSet(), expr)
+	return buf.String()
+}
+
+// numericTypes are the field types that are read directly from the
+// float64 values that JSON numbers decode to.
+var numericTypes = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true,
+}
+
+// writeUnmarshal writes the Unmarshal method for a type.
+func writeUnmarshal(buf *bytes.Buffer, typeName string, fields []field) {
+	fmt.Fprintf(buf, "// Unmarshal sets the fields of %s from params.  It was generated\n", typeName)
+	fmt.Fprintf(buf, "// by webreqgen from the type's tags.\n")
+	fmt.Fprintf(buf, "func (m *%s) Unmarshal(params objx.Map) error {\n", typeName)
+	fmt.Fprintf(buf, "\tvar missing web_request_readers.MissingFields\n")
+	for _, f := range fields {
+		if f.required == "" {
+			fmt.Fprintf(buf, "\tdefaultRequired := web_request_readers.CurrentConfig().DefaultRequired\n")
+			break
+		}
+	}
+	fmt.Fprintf(buf, "\tmatched := 0\n")
+	for _, f := range fields {
+		fmt.Fprintf(buf, "\tif value, ok := params[%q]; ok {\n", f.key)
+		fmt.Fprintf(buf, "\t\tmatched++\n")
+		fmt.Fprintf(buf, "\t\tvar err error\n")
+		fmt.Fprintf(buf, "\t\tswitch src := value.(type) {\n")
+		switch {
+		case f.typeName == "string" || f.typeName == "bool":
+			fmt.Fprintf(buf, "\t\tcase %s:\n\t\t\tm.%s = src\n", f.typeName, f.goName)
+		case f.typeName == "float64":
+			fmt.Fprintf(buf, "\t\tcase float64:\n\t\t\tm.%s = src\n", f.goName)
+		case numericTypes[f.typeName]:
+			// Values that don't survive the conversion unchanged
+			// (too large, or fractional for an integer) take the
+			// reflective path, which reports them as RangeErrors.
+			// The largest 64-bit integers round to a power of two
+			// as float64s, so they need an explicit bound.
+			check := "float64(converted) == src"
+			switch f.typeName {
+			case "int", "int64":
+				check = "src < 0x1p63 && " + check
+			case "uint", "uint64":
+				check = "src < 0x1p64 && " + check
+			}
+			fmt.Fprintf(buf, "\t\tcase float64:\n")
+			fmt.Fprintf(buf, "\t\t\tif converted := %s(src); %s {\n", f.typeName, check)
+			fmt.Fprintf(buf, "\t\t\t\tm.%s = converted\n", f.goName)
+			fmt.Fprintf(buf, "\t\t\t} else {\n")
+			fmt.Fprintf(buf, "\t\t\t\terr = web_request_readers.UnmarshalValue(src, &m.%s)\n", f.goName)
+			fmt.Fprintf(buf, "\t\t\t}\n")
+		}
+		fmt.Fprintf(buf, "\t\tdefault:\n\t\t\terr = web_request_readers.UnmarshalValue(src, &m.%s)\n", f.goName)
+		fmt.Fprintf(buf, "\t\t}\n")
+		fmt.Fprintf(buf, "\t\tif err != nil {\n")
+		fmt.Fprintf(buf, "\t\t\treturn web_request_readers.FieldError{Field: %q, Code: \"invalid\", Message: err.Error(), Err: err}\n", f.key)
+		fmt.Fprintf(buf, "\t\t}\n")
+		switch f.required {
+		case "true":
+			fmt.Fprintf(buf, "\t} else {\n")
+		case "":
+			fmt.Fprintf(buf, "\t} else if defaultRequired {\n")
+		}
+		if f.required != "false" {
+			fmt.Fprintf(buf, "\t\tmissing.AddMissing(web_request_readers.MissingField{RequestKey: %q, FieldPath: %q, Required: true})\n", f.key, f.goName)
+		}
+		fmt.Fprintf(buf, "\t}\n")
+	}
+	fmt.Fprintf(buf, "\tif matched < len(params) {\n")
+	fmt.Fprintf(buf, "\t\tvar extra web_request_readers.ExtraFields\n")
+	fmt.Fprintf(buf, "\t\tfor key := range params {\n")
+	fmt.Fprintf(buf, "\t\t\tswitch key {\n")
+	var keys []string
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if !seen[f.key] {
+			seen[f.key] = true
+			keys = append(keys, strconv.Quote(f.key))
+		}
+	}
+	if len(keys) > 0 {
+		fmt.Fprintf(buf, "\t\t\tcase %s:\n", strings.Join(keys, ", "))
+	}
+	fmt.Fprintf(buf, "\t\t\tdefault:\n\t\t\t\textra.AddExtraField(key)\n")
+	fmt.Fprintf(buf, "\t\t\t}\n\t\t}\n")
+	fmt.Fprintf(buf, "\t\tsort.Strings(extra.Names)\n")
+	fmt.Fprintf(buf, "\t\treturn extra\n")
+	fmt.Fprintf(buf, "\t}\n")
+	fmt.Fprintf(buf, "\tif missing.HasMissingFields() {\n\t\treturn missing\n\t}\n")
+	fmt.Fprintf(buf, "\treturn nil\n}\n\n")
+}
@@ -0,0 +1,236 @@
+package web_request_readers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Coercions is a set of flags describing which conversions between
+// value types are allowed when a request value doesn't have the same
+// type as the field it's being unmarshalled to.  Numbers are always
+// allowed to move between numeric kinds (JSON only has float64, after
+// all); these flags control conversions between strings, numbers, and
+// bools.
+type Coercions uint
+
+const (
+	// CoerceStringToNumber allows "42" to be unmarshalled to
+	// numeric fields.
+	CoerceStringToNumber Coercions = 1 << iota
+
+	// CoerceNumberToString allows 42 to be unmarshalled to string
+	// fields, as "42".
+	CoerceNumberToString
+
+	// CoerceStringToBool allows "true", "false", "1", "0", "on",
+	// "off", "yes", and "no" to be unmarshalled to bool fields.
+	CoerceStringToBool
+
+	// CoerceNumberToBool allows 1 and 0 to be unmarshalled to bool
+	// fields.
+	CoerceNumberToBool
+
+	// CoerceBoolToString allows true and false to be unmarshalled
+	// to string fields, as "true" and "false".
+	CoerceBoolToString
+
+	// noCoercion marks an explicitly empty set, since the zero
+	// value of Coercions means "use the default".
+	noCoercion
+)
+
+const (
+	// StrictTyping allows no coercions at all; every value must
+	// have the JSON type that matches its field.
+	StrictTyping = noCoercion
+
+	// WeakTyping allows every coercion.
+	WeakTyping = CoerceStringToNumber | CoerceNumberToString | CoerceStringToBool |
+		CoerceNumberToBool | CoerceBoolToString
+)
+
+// DefaultCoercions is used by Unmarshalers that don't set Coercions.
+// It matches this package's historical behavior, where strings (e.g.
+// from form values) could be read into numeric fields, but nothing
+// else was coerced.
+var DefaultCoercions = CoerceStringToNumber
+
+// coercionNames are the names accepted by the "coerce" tag option.
+// Each name allows values of that JSON type to be coerced to the
+// field's type.
+var coercionNames = map[string]Coercions{
+	"string": CoerceStringToNumber | CoerceStringToBool,
+	"number": CoerceNumberToString | CoerceNumberToBool,
+	"bool":   CoerceBoolToString,
+	"all":    WeakTyping,
+	"none":   StrictTyping,
+}
+
+// parseCoercions parses the value of a "coerce" tag option, which is
+// a list of coercionNames separated by "|", e.g.
+//
+//	request:"age,coerce=string"
+//	request:"id,coerce=none"
+//	request:"enabled,coerce=string|number"
+//
+// The option replaces the Unmarshaler's Coercions (and Strictness)
+// for the field, so a field can be stricter or looser than the rest
+// of its struct.
+func parseCoercions(names string) (Coercions, error) {
+	coercions := noCoercion
+	for _, name := range strings.Split(names, "|") {
+		flags, ok := coercionNames[name]
+		if !ok {
+			return 0, fmt.Errorf("Unknown coercion %s", name)
+		}
+		coercions |= flags
+	}
+	return coercions, nil
+}
+
+// resolve returns the effective set of coercions.
+func (coercions Coercions) resolve() Coercions {
+	if coercions == 0 {
+		return DefaultCoercions
+	}
+	return coercions &^ noCoercion
+}
+
+// Allows returns whether or not every flag in other is in the set.
+func (coercions Coercions) Allows(other Coercions) bool {
+	return coercions.resolve()&other == other
+}
+
+// TypeMismatch is the error returned when a request value's type
+// doesn't match its field's type and no coercion is allowed.
+type TypeMismatch struct {
+	// Expected is the kind of value the field needs, e.g.
+	// "number".
+	Expected string
+
+	// Value is the value that was in the request.
+	Value interface{}
+}
+
+// Error returns the error message for a TypeMismatch error.
+func (err TypeMismatch) Error() string {
+	return fmt.Sprintf("Expected a %s, but got %s", err.Expected, jsonTypeName(err.Value))
+}
+
+// withCoercions sets the coercions for the value currently being set,
+// returning a function that restores the previous set.
+func (state *unmarshalState) withCoercions(coercions Coercions) func() {
+	previous := state.coercions
+	state.coercions = coercions.resolve()
+	return func() {
+		state.coercions = previous
+	}
+}
+
+// coerce checks value against the kind of target, converting it if
+// the current coercions allow it.  Values for kinds that coerce
+// doesn't know about are passed through unchanged.
+func (state *unmarshalState) coerce(target reflect.Value, value interface{}) (interface{}, error) {
+	coercions := state.coercions
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		switch value.(type) {
+		case string:
+			if coercions&CoerceStringToNumber != 0 {
+				return value, nil
+			}
+		default:
+			if isNumber(value) {
+				return value, nil
+			}
+		}
+		return nil, TypeMismatch{Expected: "number", Value: value}
+	case reflect.String:
+		switch src := value.(type) {
+		case string:
+			return value, nil
+		case bool:
+			if coercions&CoerceBoolToString != 0 {
+				return strconv.FormatBool(src), nil
+			}
+		default:
+			if isNumber(value) {
+				if coercions&CoerceNumberToString != 0 {
+					return fmt.Sprint(value), nil
+				}
+			} else if reflect.TypeOf(value).Kind() == reflect.String {
+				return value, nil
+			}
+		}
+		return nil, TypeMismatch{Expected: "string", Value: value}
+	case reflect.Bool:
+		switch src := value.(type) {
+		case bool:
+			return value, nil
+		case string:
+			if coercions&CoerceStringToBool != 0 {
+				switch strings.ToLower(strings.TrimSpace(src)) {
+				case "true", "1", "on", "yes":
+					return true, nil
+				case "false", "0", "off", "no", "":
+					return false, nil
+				}
+			}
+		default:
+			if isNumber(value) && coercions&CoerceNumberToBool != 0 {
+				switch fmt.Sprint(value) {
+				case "1":
+					return true, nil
+				case "0":
+					return false, nil
+				}
+			}
+		}
+		return nil, TypeMismatch{Expected: "boolean", Value: value}
+	}
+	return value, nil
+}
+
+// isNumber returns whether or not value is one of Go's numeric types,
+// or a json.Number.
+func isNumber(value interface{}) bool {
+	if _, ok := value.(json.Number); ok {
+		return true
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// jsonTypeName describes a value using JSON's type names, which are
+// what API clients will understand.
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string, []byte:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case []interface{}, []string:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	if isNumber(value) {
+		return "a number"
+	}
+	if reflect.TypeOf(value).Kind() == reflect.Map {
+		return "an object"
+	}
+	return fmt.Sprintf("a %T", value)
+}
@@ -0,0 +1,187 @@
+package web_request_readers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stretchr/goweb/context"
+)
+
+// An ETag is an entity tag, as sent in ETag, If-Match, and
+// If-None-Match headers.
+type ETag struct {
+	// Tag is the opaque tag, without quotes.
+	Tag string
+
+	// Weak is true for weak tags, which are sent with a W/ prefix.
+	Weak bool
+}
+
+// String formats the tag as it is sent in headers, e.g. `W/"v2"`.
+func (etag ETag) String() string {
+	if etag.Weak {
+		return `W/"` + etag.Tag + `"`
+	}
+	return `"` + etag.Tag + `"`
+}
+
+// ParseETag parses a single entity tag, e.g. `"v2"` or `W/"v2"`.
+func ParseETag(value string) (ETag, error) {
+	etag, rest, ok := nextETag(strings.TrimSpace(value))
+	if !ok || rest != "" {
+		return ETag{}, InvalidFormat{Format: "entity tag", Value: value}
+	}
+	return etag, nil
+}
+
+// An ETagSet is the value of an If-Match or If-None-Match header:
+// either "*", which matches any current representation, or a list of
+// entity tags.  The zero ETagSet is an absent header.
+type ETagSet struct {
+	// Any is true when the header was "*".
+	Any bool
+
+	// Tags are the listed entity tags, in order.
+	Tags []ETag
+}
+
+// IsZero returns whether the set is empty, i.e. the header was
+// absent.
+func (set ETagSet) IsZero() bool {
+	return !set.Any && len(set.Tags) == 0
+}
+
+// MatchesStrong returns whether current matches the set using the
+// strong comparison that If-Match calls for: weak tags never match.
+func (set ETagSet) MatchesStrong(current ETag) bool {
+	if set.Any {
+		return true
+	}
+	if current.Weak {
+		return false
+	}
+	for _, etag := range set.Tags {
+		if !etag.Weak && etag.Tag == current.Tag {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesWeak returns whether current matches the set using the weak
+// comparison that If-None-Match calls for, which ignores W/ prefixes.
+func (set ETagSet) MatchesWeak(current ETag) bool {
+	if set.Any {
+		return true
+	}
+	for _, etag := range set.Tags {
+		if etag.Tag == current.Tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseETagSet parses the value of an If-Match or If-None-Match
+// header, e.g. `"a", W/"b"` or `*`.  An empty value is the zero
+// ETagSet.  ETagSet fields (e.g. with source=header) are read with
+// ParseETagSet.
+func ParseETagSet(value string) (ETagSet, error) {
+	rest := strings.TrimSpace(value)
+	if rest == "*" {
+		return ETagSet{Any: true}, nil
+	}
+	var set ETagSet
+	for {
+		rest = strings.TrimLeft(rest, " \t,")
+		if rest == "" {
+			return set, nil
+		}
+		etag, remaining, ok := nextETag(rest)
+		if !ok {
+			return ETagSet{}, InvalidFormat{Format: "entity tag list", Value: value}
+		}
+		set.Tags = append(set.Tags, etag)
+		rest = remaining
+		if rest != "" && !strings.ContainsRune(" \t,", rune(rest[0])) {
+			return ETagSet{}, InvalidFormat{Format: "entity tag list", Value: value}
+		}
+	}
+}
+
+// nextETag reads the entity tag at the start of value, returning it
+// and the rest of value.
+func nextETag(value string) (ETag, string, bool) {
+	var etag ETag
+	if strings.HasPrefix(value, "W/") {
+		etag.Weak = true
+		value = value[2:]
+	}
+	if !strings.HasPrefix(value, `"`) {
+		return ETag{}, "", false
+	}
+	end := strings.IndexByte(value[1:], '"')
+	if end == -1 {
+		return ETag{}, "", false
+	}
+	etag.Tag = value[1 : end+1]
+	return etag, value[end+2:], true
+}
+
+// Conditions holds the conditional headers of a request.  Missing
+// headers are left zero.
+type Conditions struct {
+	IfMatch           ETagSet
+	IfNoneMatch       ETagSet
+	IfModifiedSince   time.Time
+	IfUnmodifiedSince time.Time
+}
+
+// ParseConditions reads the If-Match, If-None-Match,
+// If-Modified-Since, and If-Unmodified-Since headers of a request.
+// Dates that can't be parsed are ignored, as RFC 9110 requires, but
+// malformed entity tags are returned as InvalidFormat errors.
+func ParseConditions(ctx context.Context) (Conditions, error) {
+	header := ctx.HttpRequest().Header
+	var conditions Conditions
+	var err error
+	if conditions.IfMatch, err = ParseETagSet(strings.Join(header.Values("If-Match"), ", ")); err != nil {
+		return Conditions{}, err
+	}
+	if conditions.IfNoneMatch, err = ParseETagSet(strings.Join(header.Values("If-None-Match"), ", ")); err != nil {
+		return Conditions{}, err
+	}
+	conditions.IfModifiedSince, _ = http.ParseTime(header.Get("If-Modified-Since"))
+	conditions.IfUnmodifiedSince, _ = http.ParseTime(header.Get("If-Unmodified-Since"))
+	return conditions, nil
+}
+
+// Check evaluates the conditions against the current state of the
+// requested resource, in the order RFC 9110 gives, and returns the
+// status to respond with: http.StatusOK if the request should go
+// ahead, http.StatusPreconditionFailed if it must not, or
+// http.StatusNotModified for GET and HEAD requests whose cached copy
+// is still current.  A zero modified time skips the date conditions.
+func (conditions Conditions) Check(method string, current ETag, modified time.Time) int {
+	safe := method == http.MethodGet || method == http.MethodHead
+	modified = modified.Truncate(time.Second)
+	if !conditions.IfMatch.IsZero() {
+		if !conditions.IfMatch.MatchesStrong(current) {
+			return http.StatusPreconditionFailed
+		}
+	} else if !conditions.IfUnmodifiedSince.IsZero() && !modified.IsZero() && modified.After(conditions.IfUnmodifiedSince) {
+		return http.StatusPreconditionFailed
+	}
+	if !conditions.IfNoneMatch.IsZero() {
+		if conditions.IfNoneMatch.MatchesWeak(current) {
+			if safe {
+				return http.StatusNotModified
+			}
+			return http.StatusPreconditionFailed
+		}
+	} else if safe && !conditions.IfModifiedSince.IsZero() && !modified.IsZero() && !modified.After(conditions.IfModifiedSince) {
+		return http.StatusNotModified
+	}
+	return http.StatusOK
+}
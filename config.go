@@ -0,0 +1,124 @@
+package web_request_readers
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Config holds the package-wide settings that may be changed while
+// requests are being handled.  Each unmarshal and each body parse
+// works from a snapshot of the Config taken when it starts, so a
+// change never applies halfway through a request.
+type Config struct {
+	// DefaultRequired defines whether fields are required unless
+	// tagged "optional".  It replaces the deprecated DefaultRequired
+	// variable, and starts out with its value.
+	DefaultRequired bool
+
+	// MultipartMem is the maximum number of bytes of a multipart
+	// body that are held in memory, as returned by MultipartMem.
+	MultipartMem int64
+
+	// StrictContentTypes makes ParseBody reject bodies with a
+	// Content-Type it doesn't know how to decode, with an
+	// UnsupportedMediaType error.  Otherwise, they are parsed as
+	// form data, which is what ParseBody has always done.
+	// Requests without a Content-Type are always parsed as form
+	// data, so that query parameters still work.
+	StrictContentTypes bool
+
+	// DuplicateKeys decides what happens to repeated keys in
+	// requests.  See DuplicateKeyPolicy.
+	DuplicateKeys DuplicateKeyPolicy
+
+	// Tracer, if it is set, is told about every ParseBody and
+	// UnmarshalParams call.  See SetTracer.
+	Tracer Tracer
+
+	// JSONNumbers makes ParseBody decode JSON numbers to
+	// json.Number, rather than float64, so that numbers too large
+	// or precise for a float64 (e.g. IDs and money) reach fields
+	// intact.  Numeric fields accept json.Number either way, but
+	// interface{} fields will hold json.Number values.
+	JSONNumbers bool
+
+	// BindErrorResponder, if it is set, replaces
+	// RespondWithBindError in handlers made by BindHandler.  See
+	// SetBindErrorResponder.
+	BindErrorResponder BindErrorResponder
+
+	// ParamsDataKey is the key in ctx.Data() that ParseBody caches
+	// parsed bodies under.  If it is empty, ParamsDataKey is used.
+	ParamsDataKey string
+
+	// InjectUserAgent makes ParseBody add the request's UserAgent
+	// to object bodies under UserAgentKey, replacing any value the
+	// client sent under it.
+	InjectUserAgent bool
+
+	// SignatureTolerance is how far the timestamp of a signature
+	// (for the schemes that have one) may be from the current time
+	// before VerifySignature rejects it, so that captured requests
+	// can't be replayed later.  If it is zero,
+	// DefaultSignatureTolerance is used.
+	SignatureTolerance time.Duration
+}
+
+var config atomic.Pointer[Config]
+
+// CurrentConfig returns a snapshot of the current configuration.
+//
+// Until SetConfig (or one of the setters built on it, such as
+// SetMultipartMem or SetDefaultRequired) is first called, the
+// configuration is read from the DefaultRequired variable, so
+// programs that assign DefaultRequired during initialization keep
+// working.  After that, the stored Config is authoritative and later
+// assignments to DefaultRequired are ignored.
+func CurrentConfig() Config {
+	if current := config.Load(); current != nil {
+		return *current
+	}
+	return Config{DefaultRequired: DefaultRequired, MultipartMem: multipartMem}
+}
+
+// SetConfig atomically replaces the current configuration.  It is
+// safe to call while requests are being handled.
+func SetConfig(newConfig Config) {
+	config.Store(&newConfig)
+}
+
+// updateConfig atomically applies update to the current
+// configuration, retrying if another goroutine changed it first.
+func updateConfig(update func(*Config)) {
+	for {
+		current := config.Load()
+		var next Config
+		if current != nil {
+			next = *current
+		} else {
+			next = CurrentConfig()
+		}
+		update(&next)
+		if config.CompareAndSwap(current, &next) {
+			return
+		}
+	}
+}
+
+// SetDefaultRequired atomically changes whether fields default to a
+// required state.  See Config.DefaultRequired.
+func SetDefaultRequired(required bool) {
+	updateConfig(func(c *Config) { c.DefaultRequired = required })
+}
+
+// SetStrictContentTypes atomically changes whether unknown content
+// types are rejected.  See Config.StrictContentTypes.
+func SetStrictContentTypes(strict bool) {
+	updateConfig(func(c *Config) { c.StrictContentTypes = strict })
+}
+
+// SetJSONNumbers atomically changes whether JSON numbers are decoded
+// to json.Number.  See Config.JSONNumbers.
+func SetJSONNumbers(numbers bool) {
+	updateConfig(func(c *Config) { c.JSONNumbers = numbers })
+}
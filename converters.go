@@ -0,0 +1,234 @@
+package web_request_readers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// InvalidFormat is the error returned when a request string can't be
+// parsed as the type of its field, e.g. a url.URL field given
+// "not a url".
+type InvalidFormat struct {
+	// Format names what the value should have been, e.g. "URL".
+	Format string
+
+	// Value is the string that was in the request.
+	Value string
+
+	// Err is the parser's error, if it had one.
+	Err error
+}
+
+// Error returns the error message for an InvalidFormat error.
+func (err InvalidFormat) Error() string {
+	return fmt.Sprintf("Value %q is not a valid %s", err.Value, err.Format)
+}
+
+// Unwrap returns the parser's error.
+func (err InvalidFormat) Unwrap() error {
+	return err.Err
+}
+
+// InexactNumber is the error returned when a floating-point request
+// number is given to a field with the "exact" tag option.
+type InexactNumber struct {
+	Value interface{}
+}
+
+// Error returns the error message for an InexactNumber error.
+func (err InexactNumber) Error() string {
+	return fmt.Sprintf("Value %v may have lost precision; send it as a string", err.Value)
+}
+
+// converter converts a request string to a value of the type it was
+// registered for.  Numeric converters also accept numbers, which are
+// formatted as strings first.
+type converter struct {
+	convert func(string) (reflect.Value, error)
+	numeric bool
+}
+
+// newConverter wraps a typed conversion function as a converter.
+func newConverter[T any](convert func(string) (T, error), numeric bool) converter {
+	return converter{
+		convert: func(value string) (reflect.Value, error) {
+			converted, err := convert(value)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(&converted).Elem(), nil
+		},
+		numeric: numeric,
+	}
+}
+
+var converters = map[reflect.Type]converter{
+	reflect.TypeOf(url.URL{}):      newConverter(parseURL, false),
+	reflect.TypeOf(net.IP{}):       newConverter(parseIP, false),
+	reflect.TypeOf(mail.Address{}): newConverter(parseMailAddress, false),
+	reflect.TypeOf(big.Int{}):      newConverter(parseBigInt, true),
+	reflect.TypeOf(big.Float{}):    newConverter(parseBigFloat, true),
+	reflect.TypeOf(big.Rat{}):      newConverter(parseBigRat, true),
+	reflect.TypeOf(ETag{}):         newConverter(ParseETag, false),
+	reflect.TypeOf(ETagSet{}):      newConverter(ParseETagSet, false),
+}
+
+// RegisterConverter registers a function that converts request
+// strings to values of type T.  Once registered, fields of type T (or
+// pointers to T) are set by calling convert with the request value,
+// which must be a string (or a json.Number).  Converters take
+// precedence over T's own methods, so they can also replace the way
+// that types like net.IP read themselves.
+//
+// url.URL, net.IP, and mail.Address have converters registered by
+// default.  They return InvalidFormat errors, which are reported with
+// the "format" code.
+func RegisterConverter[T any](convert func(string) (T, error)) {
+	converters[reflect.TypeOf((*T)(nil)).Elem()] = newConverter(convert, false)
+}
+
+// RegisterNumericConverter is like RegisterConverter, for types that
+// hold numbers.  Request numbers are passed to convert in their
+// shortest decimal form, except that floating-point numbers are
+// rejected with an InexactNumber error for fields with the "exact"
+// tag option, e.g.
+//
+//	request:"amount,exact"
+//
+// since they may already have lost precision.  Strings and
+// json.Numbers (see Config.JSONNumbers) are always exact.
+//
+// big.Int, big.Float, and big.Rat have numeric converters registered
+// by default.
+func RegisterNumericConverter[T any](convert func(string) (T, error)) {
+	converters[reflect.TypeOf((*T)(nil)).Elem()] = newConverter(convert, true)
+}
+
+// setConverted sets target with the converter registered for its
+// type, if there is one.  Pointers are allocated as needed.
+func (state *unmarshalState) setConverted(target reflect.Value, value interface{}) (bool, error) {
+	baseType := target.Type()
+	for baseType.Kind() == reflect.Ptr {
+		baseType = baseType.Elem()
+	}
+	conv, ok := converters[baseType]
+	if !ok {
+		return false, nil
+	}
+	for target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		target = target.Elem()
+	}
+	var str string
+	switch src := value.(type) {
+	case string:
+		str = src
+	case json.Number:
+		str = string(src)
+	default:
+		if reflect.TypeOf(value) == baseType {
+			// Default values may already have the field's type.
+			target.Set(reflect.ValueOf(value))
+			return true, nil
+		}
+		if !conv.numeric {
+			return true, TypeMismatch{Expected: "string", Value: value}
+		}
+		if !isNumber(value) {
+			return true, TypeMismatch{Expected: "number", Value: value}
+		}
+		switch src := value.(type) {
+		case float64:
+			if state.exactNumbers {
+				return true, InexactNumber{Value: value}
+			}
+			str = strconv.FormatFloat(src, 'f', -1, 64)
+		case float32:
+			if state.exactNumbers {
+				return true, InexactNumber{Value: value}
+			}
+			str = strconv.FormatFloat(float64(src), 'f', -1, 32)
+		default:
+			str = fmt.Sprint(value)
+		}
+	}
+	converted, err := conv.convert(str)
+	if err != nil {
+		return true, err
+	}
+	target.Set(converted)
+	return true, nil
+}
+
+// parseURL parses an absolute URL, e.g. "https://example.com/me" or
+// "mailto:me@example.com".
+func parseURL(value string) (url.URL, error) {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return url.URL{}, InvalidFormat{Format: "URL", Value: value, Err: err}
+	}
+	if parsed.Scheme == "" || (parsed.Host == "" && parsed.Opaque == "") {
+		return url.URL{}, InvalidFormat{Format: "URL", Value: value}
+	}
+	return *parsed, nil
+}
+
+// parseIP parses an IPv4 or IPv6 address.
+func parseIP(value string) (net.IP, error) {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, InvalidFormat{Format: "IP address", Value: value}
+	}
+	return ip, nil
+}
+
+// parseMailAddress parses an email address, optionally with a name,
+// e.g. "Bob <bob@example.com>".
+func parseMailAddress(value string) (mail.Address, error) {
+	address, err := mail.ParseAddress(value)
+	if err != nil {
+		return mail.Address{}, InvalidFormat{Format: "email address", Value: value, Err: err}
+	}
+	return *address, nil
+}
+
+// parseBigInt parses a base 10 integer of any size.
+func parseBigInt(value string) (big.Int, error) {
+	var parsed big.Int
+	if _, ok := parsed.SetString(value, 10); !ok {
+		return big.Int{}, InvalidFormat{Format: "integer", Value: value}
+	}
+	return parsed, nil
+}
+
+// parseBigFloat parses a decimal number, with enough precision for
+// every digit in value.
+func parseBigFloat(value string) (big.Float, error) {
+	prec := uint(len(value)) * 4
+	if prec < 64 {
+		prec = 64
+	}
+	parsed, _, err := big.ParseFloat(value, 10, prec, big.ToNearestEven)
+	if err != nil {
+		return big.Float{}, InvalidFormat{Format: "number", Value: value, Err: err}
+	}
+	return *parsed, nil
+}
+
+// parseBigRat parses a decimal number or a fraction, e.g. "1.25" or
+// "5/4", exactly.
+func parseBigRat(value string) (big.Rat, error) {
+	var parsed big.Rat
+	if _, ok := parsed.SetString(value); !ok {
+		return big.Rat{}, InvalidFormat{Format: "number", Value: value}
+	}
+	return parsed, nil
+}
@@ -0,0 +1,216 @@
+package web_request_readers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/stretchr/goweb/context"
+)
+
+// A CSRFScheme says how VerifyCSRF decides whether a CSRF token is
+// genuine.
+type CSRFScheme int
+
+const (
+	// CSRFDoubleSubmit requires the submitted token to equal the
+	// token in the CSRF cookie.  A cross-site page can make the
+	// browser send the cookie, but can't read it to submit it.
+	CSRFDoubleSubmit CSRFScheme = iota
+
+	// CSRFHMAC requires the submitted token to be signed with the
+	// secret for the request's session, so no cookie is needed.
+	// Both CSRFConfig.Secret and CSRFConfig.SessionID are required:
+	// a token that isn't bound to a session could be minted by an
+	// attacker with their own session and used against anyone.
+	CSRFHMAC
+)
+
+// CSRFConfig says where VerifyCSRF finds tokens and how it checks
+// them.  Empty names get the defaults shown.
+type CSRFConfig struct {
+	Scheme CSRFScheme
+
+	// HeaderName is the request header that tokens are read from
+	// ("X-CSRF-Token"), and FieldName the form field they are read
+	// from when the header is absent ("csrf_token").
+	HeaderName string
+	FieldName  string
+
+	// CookieName is the cookie that holds the token for
+	// CSRFDoubleSubmit ("csrf_token").
+	CookieName string
+
+	// Secret is the key that CSRFHMAC tokens are signed with.  It
+	// is required for CSRFHMAC.
+	Secret []byte
+
+	// SessionID returns the ID of the request's session, which
+	// CSRFHMAC tokens are bound to, so that a token can't be used
+	// with another session.  It is required for CSRFHMAC, and
+	// requests whose session ID is empty are rejected.
+	SessionID func(ctx context.Context) string
+}
+
+// ErrCSRFConfig is returned by NewCSRFToken and VerifyCSRF when a
+// CSRFHMAC config has no Secret or no SessionID.
+var ErrCSRFConfig = errors.New("CSRFHMAC requires a Secret and a SessionID")
+
+// withDefaults returns config with the default names filled in.
+func (config CSRFConfig) withDefaults() CSRFConfig {
+	if config.HeaderName == "" {
+		config.HeaderName = "X-CSRF-Token"
+	}
+	if config.FieldName == "" {
+		config.FieldName = "csrf_token"
+	}
+	if config.CookieName == "" {
+		config.CookieName = "csrf_token"
+	}
+	return config
+}
+
+// sessionID returns the session that CSRFHMAC tokens are bound to,
+// checking that the config can bind tokens at all.
+func (config CSRFConfig) sessionID(ctx context.Context) (string, error) {
+	if len(config.Secret) == 0 || config.SessionID == nil {
+		return "", ErrCSRFConfig
+	}
+	return config.SessionID(ctx), nil
+}
+
+// CSRFError is the error returned by VerifyCSRF for requests without a
+// genuine CSRF token.
+type CSRFError struct {
+	// Reason says what was wrong, e.g. "token is missing".
+	Reason string
+}
+
+// Error returns the error message for a CSRFError.
+func (err CSRFError) Error() string {
+	return "Invalid CSRF token: " + err.Reason
+}
+
+// StatusCode returns 403 Forbidden.
+func (err CSRFError) StatusCode() int {
+	return http.StatusForbidden
+}
+
+// NewCSRFToken returns a new CSRF token for a request, to be rendered
+// into forms or handed to scripts.  For CSRFDoubleSubmit, the token is
+// also set as the CSRF cookie in ResponseHeaders, so handlers must
+// write them (see WriteResponseHeaders).
+func NewCSRFToken(ctx context.Context, config CSRFConfig) (string, error) {
+	config = config.withDefaults()
+	var sessionID string
+	if config.Scheme == CSRFHMAC {
+		var err error
+		if sessionID, err = config.sessionID(ctx); err != nil {
+			return "", err
+		}
+		if sessionID == "" {
+			return "", errors.New("Cannot make a CSRF token for a request without a session")
+		}
+	}
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(nonce)
+	if config.Scheme == CSRFHMAC {
+		return encoded + "." + csrfSignature(config.Secret, sessionID, encoded), nil
+	}
+	cookie := &http.Cookie{
+		Name:     config.CookieName,
+		Value:    encoded,
+		Path:     "/",
+		Secure:   ctx.HttpRequest().TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	}
+	ResponseHeaders(ctx).Add("Set-Cookie", cookie.String())
+	return encoded, nil
+}
+
+// VerifyCSRF checks the CSRF token of a request, returning a CSRFError
+// (which SuggestedStatus reports as 403) if it isn't genuine, or if a
+// CSRFHMAC request has no session.  Safe methods (GET, HEAD, OPTIONS,
+// and TRACE) aren't checked.
+//
+// The token is read from config.HeaderName, or else from the
+// config.FieldName param of the body, which is parsed with
+// ParseParams.  The field is then removed from the cached params, so
+// that models don't need a field for it.
+func VerifyCSRF(ctx context.Context, config CSRFConfig) error {
+	switch ctx.HttpRequest().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return nil
+	}
+	config = config.withDefaults()
+	var sessionID string
+	if config.Scheme == CSRFHMAC {
+		var err error
+		if sessionID, err = config.sessionID(ctx); err != nil {
+			return err
+		}
+	}
+	token := ctx.HttpRequest().Header.Get(config.HeaderName)
+	if token == "" {
+		params, err := ParseParams(ctx)
+		if err != nil {
+			return err
+		}
+		token, _ = params[config.FieldName].(string)
+		delete(params, config.FieldName)
+	}
+	if token == "" {
+		return CSRFError{Reason: "token is missing"}
+	}
+
+	if config.Scheme == CSRFHMAC {
+		if sessionID == "" {
+			return CSRFError{Reason: "request has no session"}
+		}
+		nonce, signature, ok := strings.Cut(token, ".")
+		expected := csrfSignature(config.Secret, sessionID, nonce)
+		if !ok || !hmac.Equal([]byte(signature), []byte(expected)) {
+			return CSRFError{Reason: "token signature does not match"}
+		}
+		return nil
+	}
+	cookie, err := ctx.HttpRequest().Cookie(config.CookieName)
+	if errors.Is(err, http.ErrNoCookie) || cookie.Value == "" {
+		return CSRFError{Reason: "cookie is missing"}
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) != 1 {
+		return CSRFError{Reason: "token does not match cookie"}
+	}
+	return nil
+}
+
+// CSRFCheck returns a BindCheck that runs VerifyCSRF, so that Bind
+// rejects forged requests before unmarshalling them:
+//
+//	AddBindCheck(CSRFCheck(CSRFConfig{
+//		Scheme:    CSRFHMAC,
+//		Secret:    secret,
+//		SessionID: sessionID,
+//	}))
+func CSRFCheck(config CSRFConfig) BindCheck {
+	return func(ctx context.Context) error {
+		return VerifyCSRF(ctx, config)
+	}
+}
+
+// csrfSignature signs a CSRFHMAC nonce for a session.
+func csrfSignature(secret []byte, sessionID, nonce string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(sessionID))
+	mac.Write([]byte{0})
+	mac.Write([]byte(nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,53 @@
+package web_request_readers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Radiobox/web_request_readers/readertest"
+	"github.com/stretchr/goweb/context"
+)
+
+func TestCSRFHMACRequiresSessionBinding(t *testing.T) {
+	session := func(ctx context.Context) string { return ctx.HttpRequest().Header.Get("X-Session") }
+	request := func(method, session, token string) *readertest.Context {
+		ctx := readertest.NewContext(readertest.JSONRequest(t, method, "/", "{}"))
+		if session != "" {
+			ctx.Request.Header.Set("X-Session", session)
+		}
+		if token != "" {
+			ctx.Request.Header.Set("X-CSRF-Token", token)
+		}
+		return ctx
+	}
+
+	for _, config := range []CSRFConfig{
+		{Scheme: CSRFHMAC, Secret: []byte("secret")},
+		{Scheme: CSRFHMAC, SessionID: session},
+	} {
+		if _, err := NewCSRFToken(request("GET", "alice", ""), config); !errors.Is(err, ErrCSRFConfig) {
+			t.Errorf("NewCSRFToken accepted %+v: %v", config, err)
+		}
+		if err := VerifyCSRF(request("POST", "alice", "a.b"), config); !errors.Is(err, ErrCSRFConfig) {
+			t.Errorf("VerifyCSRF accepted %+v: %v", config, err)
+		}
+	}
+
+	config := CSRFConfig{Scheme: CSRFHMAC, Secret: []byte("secret"), SessionID: session}
+	if _, err := NewCSRFToken(request("GET", "", ""), config); err == nil {
+		t.Error("NewCSRFToken made a token without a session")
+	}
+	token, err := NewCSRFToken(request("GET", "mallory", ""), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyCSRF(request("POST", "mallory", token), config); err != nil {
+		t.Errorf("own token rejected: %s", err)
+	}
+	if err := VerifyCSRF(request("POST", "alice", token), config); SuggestedStatus(err) != 403 {
+		t.Errorf("token accepted for another session: %v", err)
+	}
+	if err := VerifyCSRF(request("POST", "", token), config); SuggestedStatus(err) != 403 {
+		t.Errorf("token accepted without a session: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A TraceAction is what happened to a single struct field during an
+// unmarshal, as recorded in a DebugTrace.
+type TraceAction string
+
+const (
+	// TraceSet means the field was set from the request.
+	TraceSet TraceAction = "set"
+
+	// TraceFailed means the field had a value in the request, but
+	// it couldn't be used.
+	TraceFailed TraceAction = "failed"
+
+	// TraceDefaulted means the field wasn't in the request and was
+	// given a default value.
+	TraceDefaulted TraceAction = "defaulted"
+
+	// TraceMissing means the field wasn't in the request and was
+	// reported in MissingFields.
+	TraceMissing TraceAction = "missing"
+
+	// TraceAbsent means the field wasn't in the request, but
+	// didn't need to be, so it was left alone.
+	TraceAbsent TraceAction = "absent"
+
+	// TraceSkipped means the field was in the request, but was
+	// deliberately ignored, e.g. because the caller didn't have
+	// its scope.
+	TraceSkipped TraceAction = "skipped"
+
+	// TraceForbidden means the field was in the request, but was
+	// reported in ForbiddenFields.
+	TraceForbidden TraceAction = "forbidden"
+
+	// TraceIgnored means the field can never be set from a
+	// request, because it is tagged "-" or is unexported.
+	TraceIgnored TraceAction = "ignored"
+)
+
+// A TraceEntry records how a single struct field was resolved.
+type TraceEntry struct {
+	// Field is the dotted path of struct field names, e.g.
+	// "Address.Street".
+	Field string
+
+	// Key is the dotted path of the request key that the field was
+	// read from, or that it expected if it wasn't in the request.
+	Key string
+
+	Action TraceAction
+
+	// Detail explains the action where that helps, e.g. the error
+	// for TraceFailed.
+	Detail string
+}
+
+// A DebugTrace records which request key mapped to which struct
+// field during an unmarshal, and what happened to every field that
+// wasn't set.  It is collected when Unmarshaler.Debug is set, and is
+// available as Result.Trace (see UnmarshalParamsResult), even when
+// the unmarshal fails.
+type DebugTrace struct {
+	Entries []TraceEntry
+}
+
+// String returns the trace with one entry per line, e.g.
+//
+//	Address.Street <- address.street: set
+//	Email <- email: missing
+func (trace *DebugTrace) String() string {
+	var lines []string
+	for _, entry := range trace.Entries {
+		line := fmt.Sprintf("%s <- %s: %s", entry.Field, entry.Key, entry.Action)
+		if entry.Detail != "" {
+			line += " (" + entry.Detail + ")"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Entry returns the entry for the struct field at the dotted path
+// field, if there is one.
+func (trace *DebugTrace) Entry(field string) (TraceEntry, bool) {
+	for _, entry := range trace.Entries {
+		if entry.Field == field {
+			return entry, true
+		}
+	}
+	return TraceEntry{}, false
+}
+
+// trace records what happened to a field, if a DebugTrace is being
+// collected.
+func (state *unmarshalState) trace(goName, key string, action TraceAction, detail string) {
+	if state.result.Trace == nil {
+		return
+	}
+	state.result.Trace.Entries = append(state.result.Trace.Entries, TraceEntry{
+		Field:  state.fieldPath + goName,
+		Key:    key,
+		Action: action,
+		Detail: detail,
+	})
+}
+
+// matchedKey returns the request key that lookup would read a field
+// from, with the key path of the struct being unmarshalled.
+func (state *unmarshalState) matchedKey(keys []string, fold bool) string {
+	for _, name := range keys {
+		if key, ok := state.findKey(name, fold); ok {
+			return state.keyPath + key
+		}
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+// Package decimal registers web_request_readers converters for
+// github.com/shopspring/decimal, so that money and other fixed-point
+// fields read from requests without going through float64:
+//
+//	decimal.Install()
+//
+//	type CreateCharge struct {
+//		Amount shopspring.Decimal `request:"amount,exact"`
+//	}
+//
+// Decimals are read from strings and json.Numbers (see
+// web_request_readers.Config.JSONNumbers) exactly.  Floating-point
+// numbers are accepted too, unless the field has the "exact" tag
+// option.
+package decimal
+
+import (
+	web_request_readers "github.com/Radiobox/web_request_readers"
+	"github.com/shopspring/decimal"
+)
+
+// Install registers converters for decimal.Decimal and
+// decimal.NullDecimal.  Like
+// web_request_readers.RegisterNumericConverter, it should be called
+// before any requests are handled.
+func Install() {
+	web_request_readers.RegisterNumericConverter(parse)
+	web_request_readers.RegisterNumericConverter(func(value string) (decimal.NullDecimal, error) {
+		parsed, err := parse(value)
+		return decimal.NullDecimal{Decimal: parsed, Valid: err == nil}, err
+	})
+}
+
+func parse(value string) (decimal.Decimal, error) {
+	parsed, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero, web_request_readers.InvalidFormat{Format: "decimal number", Value: value, Err: err}
+	}
+	return parsed, nil
+}
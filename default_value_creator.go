@@ -1,8 +1,59 @@
 package web_request_readers
 
+import (
+	"reflect"
+
+	"github.com/stretchr/goweb/context"
+)
+
 // DefaultValueCreator is a type that creates a default value for when
-// it's not part of a request but is an optional field.
+// it's not part of a request.  Defaults are applied to optional and
+// required fields alike, although a required field with a default is
+// still reported in MissingFields unless the Unmarshaler's
+// DefaultsSatisfyRequired option is set.
+//
+// The method may be implemented on either the field's type or a
+// pointer to it; nil pointer fields are checked against their element
+// type, so a *Currency field works if Currency is a
+// DefaultValueCreator.
 type DefaultValueCreator interface {
 	// DefaultValue should return the default value of this type.
 	DefaultValue() interface{}
 }
+
+// ContextDefaultValueCreator is a type that creates a default value
+// based on the request being unmarshalled, e.g. a default currency
+// that depends on the requesting user's region.  It is only used by
+// the UnmarshalRequestParams variants (and Bind), since
+// UnmarshalParams has no request to pass along.  It takes priority
+// over DefaultValueCreator when both are implemented.
+type ContextDefaultValueCreator interface {
+	// DefaultValueFor should return the default value of this type
+	// for the request in ctx.
+	DefaultValueFor(ctx context.Context) interface{}
+}
+
+// defaultFor returns the default value for a field that was missing
+// from the request, if its type provides one.
+func (state *unmarshalState) defaultFor(field reflect.Value) (interface{}, bool) {
+	candidates := []reflect.Value{field}
+	if field.Kind() == reflect.Ptr && field.IsNil() {
+		// Calling methods on a nil pointer isn't safe, so ask a
+		// freshly allocated value instead.
+		newValue := reflect.New(field.Type().Elem())
+		candidates = []reflect.Value{newValue, newValue.Elem()}
+	} else if field.CanAddr() {
+		candidates = append(candidates, field.Addr())
+	}
+	for _, candidate := range candidates {
+		if defaulter, ok := candidate.Interface().(ContextDefaultValueCreator); ok && state.ctx != nil {
+			return defaulter.DefaultValueFor(state.ctx), true
+		}
+	}
+	for _, candidate := range candidates {
+		if defaulter, ok := candidate.Interface().(DefaultValueCreator); ok {
+			return defaulter.DefaultValue(), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,47 @@
+package web_request_readers
+
+import (
+	"testing"
+
+	"github.com/stretchr/objx"
+)
+
+type testCountry string
+
+func (country testCountry) DefaultValue() interface{} {
+	return "US"
+}
+
+type testRegion string
+
+func (region testRegion) DefaultValue() interface{} {
+	return "EU"
+}
+
+type testAddress struct {
+	Street  string      `request:"street"`
+	Country testCountry `request:"country,optional"`
+}
+
+type testCustomer struct {
+	Country testRegion  `request:"country,optional"`
+	Address testAddress `request:"address"`
+}
+
+func TestReplayDefaultsUsesNestedKeys(t *testing.T) {
+	unmarshaler := &Unmarshaler{ReplayDefaults: true}
+	params := objx.Map{"address": objx.Map{"street": "1 Main St"}}
+	result, err := unmarshaler.UnmarshalParamsResult(params, &testCustomer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Defaults["country"] != "EU" || result.Defaults["address.country"] != "US" {
+		t.Errorf("unexpected defaults %v", result.Defaults)
+	}
+	if params["country"] != "EU" {
+		t.Errorf("top-level default replayed as %v", params["country"])
+	}
+	if address := params["address"].(objx.Map); address["country"] != "US" {
+		t.Errorf("nested default replayed as %v", address["country"])
+	}
+}
@@ -0,0 +1,124 @@
+package web_request_readers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/stretchr/goweb/context"
+)
+
+const responseHeadersDataKey = "response_headers"
+
+// A ProfiledModel is a model that names the binding profile (usually
+// a model version, e.g. "user.v1") that it represents.  Bind uses the
+// profile to look up deprecation information for the request.
+type ProfiledModel interface {
+	BindingProfile() string
+}
+
+// Deprecation describes the deprecation schedule of a binding
+// profile.
+type Deprecation struct {
+	// Deprecated is the time that the profile was (or will be)
+	// deprecated.  A zero value means "deprecated now".
+	Deprecated time.Time
+
+	// Sunset is the time that the profile will stop being
+	// accepted.  A zero value means no sunset has been scheduled.
+	Sunset time.Time
+
+	// Link is an optional URL pointing at documentation about the
+	// deprecation (e.g. a migration guide).
+	Link string
+}
+
+var deprecations = make(map[string]Deprecation)
+
+// DeprecateProfile marks a binding profile as deprecated.  Any request
+// bound to a ProfiledModel using that profile will have Deprecation
+// and Sunset headers added to ResponseHeaders.
+func DeprecateProfile(profile string, deprecation Deprecation) {
+	deprecations[profile] = deprecation
+}
+
+// ProfileDeprecation returns the deprecation schedule for profile, if
+// it has been deprecated.
+func ProfileDeprecation(profile string) (Deprecation, bool) {
+	deprecation, ok := deprecations[profile]
+	return deprecation, ok
+}
+
+// Header returns the response headers that signal this deprecation to
+// clients: Deprecation (draft-ietf-httpapi-deprecation-header), Sunset
+// (RFC 8594), and a Link with the relevant rel, if there is a link.
+func (deprecation Deprecation) Header() http.Header {
+	header := make(http.Header)
+	if deprecation.Deprecated.IsZero() {
+		header.Set("Deprecation", "true")
+	} else {
+		header.Set("Deprecation", "@"+strconv.FormatInt(deprecation.Deprecated.Unix(), 10))
+	}
+	if !deprecation.Sunset.IsZero() {
+		header.Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if deprecation.Link != "" {
+		rel := "deprecation"
+		if !deprecation.Sunset.IsZero() {
+			rel = "sunset"
+		}
+		header.Add("Link", "<"+deprecation.Link+`>; rel="`+rel+`"`)
+	}
+	return header
+}
+
+// ResponseHeaders returns the headers that this package thinks should
+// be added to the response for a request.  You can either copy them
+// yourself or call WriteResponseHeaders.
+func ResponseHeaders(ctx context.Context) http.Header {
+	if header, ok := ctx.Data()[responseHeadersDataKey].(http.Header); ok {
+		return header
+	}
+	header := make(http.Header)
+	ctx.Data().Set(responseHeadersDataKey, header)
+	return header
+}
+
+// WriteResponseHeaders copies ResponseHeaders to the response writer.
+// It must be called before the response is written.
+func WriteResponseHeaders(ctx context.Context) {
+	dest := ctx.HttpResponseWriter().Header()
+	for key, values := range ResponseHeaders(ctx) {
+		for _, value := range values {
+			dest.Add(key, value)
+		}
+	}
+}
+
+// addDeprecationHeaders adds deprecation headers to ResponseHeaders if
+// target is a ProfiledModel with a deprecated profile.  Deprecation and
+// Sunset replace any earlier values, and Link values that are already
+// there aren't repeated, so binding a request twice doesn't duplicate
+// them.
+func addDeprecationHeaders(ctx context.Context, target interface{}) {
+	profiled, ok := target.(ProfiledModel)
+	if !ok {
+		return
+	}
+	deprecation, ok := ProfileDeprecation(profiled.BindingProfile())
+	if !ok {
+		return
+	}
+	header := ResponseHeaders(ctx)
+	for key, values := range deprecation.Header() {
+		if key != "Link" {
+			header[key] = values
+			continue
+		}
+		for _, value := range values {
+			if !containsString(header.Values(key), value) {
+				header.Add(key, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package web_request_readers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Radiobox/web_request_readers/readertest"
+)
+
+type testProfiled struct {
+	Name string `request:"name"`
+}
+
+func (testProfiled) BindingProfile() string {
+	return "test-v1"
+}
+
+func TestBindTwiceKeepsOneDeprecation(t *testing.T) {
+	DeprecateProfile("test-v1", Deprecation{Sunset: time.Now().Add(time.Hour), Link: "https://example.com/v2"})
+	defer delete(deprecations, "test-v1")
+
+	ctx := readertest.NewContext(readertest.JSONRequest(t, "POST", "/", `{"name": "x"}`))
+	ResponseHeaders(ctx).Add("Link", `<https://example.com/docs>; rel="help"`)
+	for i := 0; i < 2; i++ {
+		if err := Bind(ctx, &testProfiled{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	header := ResponseHeaders(ctx)
+	for key, want := range map[string]int{"Deprecation": 1, "Sunset": 1, "Link": 2} {
+		if got := len(header.Values(key)); got != want {
+			t.Errorf("%s: got %d values, want %d: %v", key, got, want, header.Values(key))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// DefaultMaxDepth is the maximum nesting depth of structs that an
+// Unmarshaler with a zero MaxDepth will unmarshal.
+const DefaultMaxDepth = 32
+
+// A NestingError is returned when request params are nested too
+// deeply, or refer back to themselves, for a nested struct field.
+// Either would otherwise let a hostile request (or a recursive model
+// type) recurse without bound.
+type NestingError struct {
+	// Field is the dotted path of request keys of the nested
+	// field that failed.
+	Field string
+
+	// MaxDepth is the depth limit that was exceeded, or zero if
+	// Cycle is true.
+	MaxDepth int
+
+	// Cycle is true if the params for Field are the same map as
+	// the params of one of its parents.
+	Cycle bool
+}
+
+// Error returns the error message for a NestingError.
+func (err NestingError) Error() string {
+	if err.Cycle {
+		return fmt.Sprintf("Params for %s contain themselves", err.Field)
+	}
+	return fmt.Sprintf("Params for %s are nested more than %d levels deep", err.Field, err.MaxDepth)
+}
+
+// maxDepth returns the unmarshaler's MaxDepth, or DefaultMaxDepth if
+// it is zero.
+func (unmarshaler *Unmarshaler) maxDepth() int {
+	if unmarshaler.MaxDepth == 0 {
+		return DefaultMaxDepth
+	}
+	return unmarshaler.MaxDepth
+}
+
+// checkNesting returns a NestingError if unmarshalling params to the
+// field currently being set would go past the maximum depth or loop
+// back to a parent's params.
+func (state *unmarshalState) checkNesting(params map[string]interface{}) error {
+	field := state.keyPath + state.fieldName
+	if max := state.unmarshaler.maxDepth(); max > 0 && len(state.ancestors) >= max {
+		return NestingError{Field: field, MaxDepth: max}
+	}
+	pointer := reflect.ValueOf(params).Pointer()
+	if pointer == 0 {
+		return nil
+	}
+	if pointer == reflect.ValueOf(state.params).Pointer() {
+		return NestingError{Field: field, Cycle: true}
+	}
+	for _, ancestor := range state.ancestors {
+		if ancestor == pointer {
+			return NestingError{Field: field, Cycle: true}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package web_request_readers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"strings"
+
+	"github.com/stretchr/goweb/context"
+	"github.com/stretchr/objx"
+)
+
+const documentsDataKey = "documents"
+
+// recordSeparator starts each record in an RFC 7464 JSON text
+// sequence.
+const recordSeparator = 0x1E
+
+// ParseDocuments parses a request body that contains a sequence of
+// JSON documents, returning them in order.  The following bodies are
+// supported:
+//
+// 1. application/json-seq (RFC 7464), where each document is preceded
+// by an ASCII record separator.
+//
+// 2. multipart/mixed (or any other multipart type), where each part
+// is a JSON document.
+//
+// 3. application/json containing a top-level array of objects.
+//
+// Each document must be a JSON object, so that it can be passed
+// straight to UnmarshalParams.  The result is cached in ctx.Data().
+func ParseDocuments(ctx context.Context) ([]objx.Map, error) {
+	if docs, ok := ctx.Data()[documentsDataKey].([]objx.Map); ok {
+		return docs, nil
+	}
+	request := ctx.HttpRequest()
+	mimeType, mimeParams, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+
+	var raw [][]byte
+	switch {
+	case mimeType == "application/json-seq":
+		body, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range bytes.Split(body, []byte{recordSeparator}) {
+			if record = bytes.TrimSpace(record); len(record) > 0 {
+				raw = append(raw, record)
+			}
+		}
+	case strings.HasPrefix(mimeType, "multipart/"):
+		boundary := mimeParams["boundary"]
+		if boundary == "" {
+			return nil, errors.New("Multipart body has no boundary")
+		}
+		reader := multipart.NewReader(request.Body, boundary)
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			body, err := ioutil.ReadAll(part)
+			part.Close()
+			if err != nil {
+				return nil, err
+			}
+			raw = append(raw, body)
+		}
+	case mimeType == "application/json" || mimeType == "text/json":
+		var body []json.RawMessage
+		if err := json.NewDecoder(request.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		for _, doc := range body {
+			raw = append(raw, doc)
+		}
+	default:
+		return nil, fmt.Errorf("Cannot read documents from a %s body", mimeType)
+	}
+
+	docs := make([]objx.Map, 0, len(raw))
+	for index, doc := range raw {
+		var value interface{}
+		if err := json.Unmarshal(doc, &value); err != nil {
+			return nil, fmt.Errorf("Document %d: %s", index, err)
+		}
+		params, ok := ConvertMSIToObjxMap(value).(objx.Map)
+		if !ok {
+			return nil, fmt.Errorf("Document %d is not a JSON object", index)
+		}
+		docs = append(docs, params)
+	}
+	ctx.Data().Set(documentsDataKey, docs)
+	return docs, nil
+}
@@ -0,0 +1,163 @@
+package web_request_readers
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A DuplicateKeyPolicy decides what happens when a request repeats a
+// key: a JSON object with the same key twice, or a form that sends
+// several values for a key whose field only holds one.  Repeated keys
+// are a common sign of HTTP parameter pollution.
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeysIgnore keeps the old behavior: the last of a
+	// JSON object's repeated keys wins, and a form's repeated
+	// values are converted as usual.  This is the default.
+	DuplicateKeysIgnore DuplicateKeyPolicy = iota
+
+	// DuplicateKeysReport keeps the old behavior, but records the
+	// repeated keys in ParsedContent.DuplicateKeys (for JSON) and
+	// Result.DuplicateKeys (for form values sent to a scalar
+	// field, and for request keys that KeyNormalizers make the
+	// same).
+	DuplicateKeysReport
+
+	// DuplicateKeysReject makes ParseBody return a DuplicateKeys
+	// error for JSON bodies with repeated keys, and UnmarshalParams
+	// return a FieldError with the code "duplicate" for form
+	// values sent to a scalar field, and a DuplicateKeys error for
+	// request keys that KeyNormalizers make the same.
+	DuplicateKeysReject
+)
+
+// DuplicateKeys is an error type that stores a list of keys that
+// were repeated in a request.
+type DuplicateKeys struct {
+	// Names stores the dotted paths of the repeated keys, e.g.
+	// "address.city" or "items[2].id".
+	Names []string
+}
+
+// Error returns the error message for a DuplicateKeys error.
+func (err DuplicateKeys) Error() string {
+	return "Duplicate values for keys: " + strings.Join(err.Names, ",")
+}
+
+// ToResponse returns the ErrorResponse for a DuplicateKeys error,
+// with the code "duplicate" for each key.
+func (err DuplicateKeys) ToResponse() ErrorResponse {
+	response := ErrorResponse{Errors: make([]ResponseError, len(err.Names))}
+	for index, name := range err.Names {
+		response.Errors[index] = ResponseError{
+			Field:   name,
+			Code:    "duplicate",
+			Message: "Duplicate values for " + name,
+		}
+	}
+	return response
+}
+
+// MarshalJSON encodes a DuplicateKeys error as an ErrorResponse.
+func (err DuplicateKeys) MarshalJSON() ([]byte, error) {
+	return json.Marshal(err.ToResponse())
+}
+
+// SetDuplicateKeys atomically changes the policy for repeated keys.
+// See Config.DuplicateKeys.
+func SetDuplicateKeys(policy DuplicateKeyPolicy) {
+	updateConfig(func(c *Config) { c.DuplicateKeys = policy })
+}
+
+// duplicateJSONKeys returns the paths of the keys that are repeated
+// within an object anywhere in a JSON document.  body must already be
+// known to be valid JSON.
+func duplicateJSONKeys(body []byte) []string {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	var duplicates []string
+	scanJSONValue(decoder, "", &duplicates)
+	return duplicates
+}
+
+// scanJSONValue reads one value from decoder, appending the paths of
+// any repeated object keys in it to duplicates.
+func scanJSONValue(decoder *json.Decoder, path string, duplicates *[]string) {
+	token, err := decoder.Token()
+	if err != nil {
+		return
+	}
+	switch token {
+	case json.Delim('{'):
+		seen := make(map[string]bool)
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return
+			}
+			key, _ := keyToken.(string)
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			if seen[key] {
+				*duplicates = append(*duplicates, keyPath)
+			}
+			seen[key] = true
+			scanJSONValue(decoder, keyPath, duplicates)
+		}
+		decoder.Token()
+	case json.Delim('['):
+		for index := 0; decoder.More(); index++ {
+			scanJSONValue(decoder, path+"["+strconv.Itoa(index)+"]", duplicates)
+		}
+		decoder.Token()
+	}
+}
+
+// checkDuplicateValues applies the duplicate key policy to a request
+// value for field, which is a duplicate if it is a list of several
+// form values and field only holds one value.
+func (state *unmarshalState) checkDuplicateValues(field reflect.Value, name string, args []string, value interface{}) error {
+	if state.duplicateKeys == DuplicateKeysIgnore {
+		return nil
+	}
+	if values, ok := value.([]string); !ok || len(values) < 2 {
+		return nil
+	}
+	fieldType := field.Type()
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	switch fieldType.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Interface:
+		return nil
+	}
+	key := state.keyPath + name
+	if state.duplicateKeys == DuplicateKeysReport {
+		state.result.DuplicateKeys = append(state.result.DuplicateKeys, key)
+		return nil
+	}
+	err := DuplicateKeys{Names: []string{key}}
+	return state.fieldError(name, args, "duplicate", value, err)
+}
+
+// checkKeyCollisions applies the duplicate key policy to the request
+// keys that the unmarshaler's KeyNormalizers made the same.
+func (state *unmarshalState) checkKeyCollisions() error {
+	if len(state.keyCollisions) == 0 || state.duplicateKeys == DuplicateKeysIgnore {
+		return nil
+	}
+	names := make([]string, len(state.keyCollisions))
+	for index, key := range state.keyCollisions {
+		names[index] = state.keyPath + key
+	}
+	if state.duplicateKeys == DuplicateKeysReport {
+		state.result.DuplicateKeys = append(state.result.DuplicateKeys, names...)
+		return nil
+	}
+	return DuplicateKeys{Names: names}
+}
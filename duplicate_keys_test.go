@@ -0,0 +1,48 @@
+package web_request_readers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/objx"
+)
+
+func TestNormalizedKeyCollisions(t *testing.T) {
+	defer SetDuplicateKeys(DuplicateKeysIgnore)
+	unmarshaler := &Unmarshaler{KeyNormalizers: []KeyNormalizer{TrimKey, FoldKey}}
+	params := func() objx.Map {
+		return objx.Map{"Body": "upper", " body": "spaced", "body": "plain", "BODY ": "shouted"}
+	}
+
+	// Whatever order the map is read in, the already normalized key
+	// wins.
+	for i := 0; i < 20; i++ {
+		var comment testComment
+		if err := unmarshaler.UnmarshalParams(params(), &comment); err != nil || comment.Body != "plain" {
+			t.Fatalf("ignore: got %q, %v", comment.Body, err)
+		}
+	}
+	// Otherwise, the lowest key does.
+	for i := 0; i < 20; i++ {
+		var comment testComment
+		if err := unmarshaler.UnmarshalParams(objx.Map{"Body": "upper", "BODY ": "shouted"}, &comment); err != nil || comment.Body != "shouted" {
+			t.Fatalf("ignore without a normalized key: got %q, %v", comment.Body, err)
+		}
+	}
+
+	SetDuplicateKeys(DuplicateKeysReport)
+	result, err := unmarshaler.UnmarshalParamsResult(params(), &testComment{})
+	if err != nil || !reflect.DeepEqual(result.DuplicateKeys, []string{"body"}) {
+		t.Errorf("report: got %v, %v", result.DuplicateKeys, err)
+	}
+
+	SetDuplicateKeys(DuplicateKeysReject)
+	var duplicates DuplicateKeys
+	if err := unmarshaler.UnmarshalParams(params(), &testComment{}); !errors.As(err, &duplicates) || !reflect.DeepEqual(duplicates.Names, []string{"body"}) {
+		t.Errorf("reject: got %v", err)
+	}
+	if err := unmarshaler.UnmarshalParams(objx.Map{"body": "plain"}, &testComment{}); err != nil {
+		t.Errorf("reject without a collision: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package web_request_readers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// durationUnits are the unit names accepted by the "duration" tag
+// option, e.g.
+//
+//	request:"timeout,duration=ms"
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// durationUnit returns the unit that plain numbers are read in for
+// time.Duration fields.
+func (unmarshaler *Unmarshaler) durationUnit() time.Duration {
+	if unmarshaler.DurationUnit == 0 {
+		return time.Second
+	}
+	return unmarshaler.DurationUnit
+}
+
+// setDuration sets a time.Duration field.  Strings are parsed with
+// time.ParseDuration (e.g. "30s" or "1h15m"); numbers, and strings
+// that are just a number, are read as a count of unit.
+func setDuration(target reflect.Value, value interface{}, unit time.Duration) error {
+	var count float64
+	switch src := value.(type) {
+	case string:
+		if parsed, err := time.ParseDuration(src); err == nil {
+			target.SetInt(int64(parsed))
+			return nil
+		}
+		num, err := strconv.ParseFloat(src, 64)
+		if err != nil {
+			return fmt.Errorf("Cannot parse %q as a duration", src)
+		}
+		count = num
+	case json.Number:
+		num, err := src.Float64()
+		if err != nil {
+			return fmt.Errorf("Cannot parse %q as a duration", src)
+		}
+		count = num
+	case float64:
+		count = src
+	case float32:
+		count = float64(src)
+	case int:
+		count = float64(src)
+	case int64:
+		count = float64(src)
+	case time.Duration:
+		target.SetInt(int64(src))
+		return nil
+	default:
+		return fmt.Errorf("Cannot read a duration from a %T value", value)
+	}
+	target.SetInt(int64(count * float64(unit)))
+	return nil
+}
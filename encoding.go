@@ -0,0 +1,41 @@
+package web_request_readers
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+)
+
+// setEncoded sets target using the standard library's decoding
+// interfaces, returning false if target implements neither of them.
+// String values are passed to encoding.TextUnmarshaler if target
+// implements it; anything else (including strings, for types that
+// only read JSON) is re-encoded and passed to json.Unmarshaler.
+//
+// Types that implement Unmarshaller are left alone when the value is
+// an object, so that setStruct can run the usual lifecycle for them.
+func setEncoded(target reflect.Value, value interface{}) (bool, error) {
+	if !target.CanAddr() {
+		return false, nil
+	}
+	targetPtr := target.Addr().Interface()
+	if str, ok := value.(string); ok {
+		if textUnmarshaler, ok := targetPtr.(encoding.TextUnmarshaler); ok {
+			return true, textUnmarshaler.UnmarshalText([]byte(str))
+		}
+	}
+	jsonUnmarshaler, ok := targetPtr.(json.Unmarshaler)
+	if !ok {
+		return false, nil
+	}
+	if _, isParams := asParams(value); isParams {
+		if _, ok := targetPtr.(Unmarshaller); ok {
+			return false, nil
+		}
+	}
+	fragment, err := json.Marshal(value)
+	if err != nil {
+		return true, err
+	}
+	return true, jsonUnmarshaler.UnmarshalJSON(fragment)
+}
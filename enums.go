@@ -0,0 +1,119 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// InvalidEnumValue is the error type returned when a request value
+// isn't one of the values allowed for a field, either by its enum tag
+// option or by an enum registered with RegisterEnum.
+type InvalidEnumValue struct {
+	Value   interface{}
+	Allowed []string
+}
+
+// Error returns the error message for an InvalidEnumValue error.
+func (err InvalidEnumValue) Error() string {
+	return fmt.Sprintf("Value %v is not one of: %s", err.Value, strings.Join(err.Allowed, ", "))
+}
+
+// enumTable maps the names of an enum type's values to the values.
+type enumTable struct {
+	names  []string
+	values map[string]reflect.Value
+}
+
+var enums = make(map[reflect.Type]enumTable)
+
+// RegisterEnum registers the names of an enum type's values, so that
+// requests can use the names instead of the underlying values.  For
+// example, after
+//
+//	RegisterEnum(map[string]Status{
+//		"active":   StatusActive,
+//		"inactive": StatusInactive,
+//	})
+//
+// a Status field accepts "active" and "inactive".  Requests may still
+// send the underlying values (e.g. 0 or 1), but values that aren't in
+// names are rejected with an InvalidEnumValue error.
+func RegisterEnum[T any](names map[string]T) {
+	table := enumTable{values: make(map[string]reflect.Value, len(names))}
+	for name, value := range names {
+		table.names = append(table.names, name)
+		table.values[name] = reflect.ValueOf(value)
+	}
+	sort.Strings(table.names)
+	enums[reflect.TypeOf((*T)(nil)).Elem()] = table
+}
+
+// setEnum sets target to the registered enum value named by value.
+// It returns false if target's type isn't a registered enum or value
+// isn't a name, in which case the value should be set as usual and
+// then passed to checkEnum.
+func setEnum(target reflect.Value, value interface{}) (bool, error) {
+	table, ok := enums[target.Type()]
+	if !ok {
+		return false, nil
+	}
+	name, ok := value.(string)
+	if !ok {
+		return false, nil
+	}
+	if enumValue, ok := table.values[name]; ok {
+		target.Set(enumValue)
+		return true, nil
+	}
+	if _, err := strconv.ParseFloat(name, 64); err == nil {
+		// A number sent as a string; let the usual conversion
+		// (and checkEnum) deal with it.
+		return false, nil
+	}
+	return true, InvalidEnumValue{Value: value, Allowed: table.names}
+}
+
+// checkEnum checks that target holds one of its registered enum
+// values, if its type is a registered enum.
+func checkEnum(target reflect.Value, value interface{}) error {
+	table, ok := enums[target.Type()]
+	if !ok {
+		return nil
+	}
+	for _, enumValue := range table.values {
+		if enumValue.Interface() == target.Interface() {
+			return nil
+		}
+	}
+	return InvalidEnumValue{Value: value, Allowed: table.names}
+}
+
+// checkEnumOption checks value against the choices in an enum tag
+// option, e.g. enum=active|inactive|banned.  Strings must match a
+// choice exactly; numbers are compared using their shortest decimal
+// form.
+func checkEnumOption(choices string, value interface{}) error {
+	var str string
+	switch src := value.(type) {
+	case string:
+		str = src
+	case float64:
+		str = strconv.FormatFloat(src, 'f', -1, 64)
+	case float32:
+		str = strconv.FormatFloat(float64(src), 'f', -1, 32)
+	case int:
+		str = strconv.Itoa(src)
+	case int64:
+		str = strconv.FormatInt(src, 10)
+	default:
+		str = fmt.Sprint(value)
+	}
+	allowed := strings.Split(choices, "|")
+	if !containsString(allowed, str) {
+		return InvalidEnumValue{Value: value, Allowed: allowed}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/stretchr/goweb/context"
+)
+
+const assignmentsDataKey = "experiment_assignments"
+
+var (
+	// ExperimentHeader is the request header that experiment
+	// assignments are read from.  The expected format is a
+	// comma-separated list of experiment=variant pairs, e.g.
+	// "checkout=variant_b, onboarding=control".
+	ExperimentHeader = "X-Experiments"
+
+	// ExperimentQueryParam is the query parameter that experiment
+	// assignments are read from when ExperimentHeader is not set.
+	// It uses the same format as ExperimentHeader.
+	ExperimentQueryParam = "experiments"
+)
+
+var experiments = make(map[string][]string)
+
+// RegisterExperiment registers an experiment and the variants that
+// requests may be assigned to.  Assignments for experiments that have
+// not been registered are dropped by ParseAssignments, so that stale
+// clients can't bucket themselves into experiments that no longer
+// exist.
+func RegisterExperiment(name string, variants ...string) {
+	experiments[name] = variants
+}
+
+// Assignments maps experiment names to the variant that a request has
+// been assigned to.
+type Assignments map[string]string
+
+// Variant returns the variant that the request was assigned to for
+// experiment, or an empty string if the request has no assignment.
+func (assignments Assignments) Variant(experiment string) string {
+	return assignments[experiment]
+}
+
+// In returns whether or not the request was assigned to variant for
+// experiment.
+func (assignments Assignments) In(experiment, variant string) bool {
+	return assignments[experiment] == variant
+}
+
+// InvalidAssignment is the error type returned when a request is
+// assigned to a variant that is not registered for an experiment.
+type InvalidAssignment struct {
+	Experiment string
+	Variant    string
+	Allowed    []string
+}
+
+// Error returns the error message for an InvalidAssignment error.
+func (err InvalidAssignment) Error() string {
+	return fmt.Sprintf("Invalid variant %s for experiment %s; must be one of: %s",
+		err.Variant, err.Experiment, strings.Join(err.Allowed, ","))
+}
+
+// ParseAssignments reads experiment assignments from a request, using
+// ExperimentHeader or (if the header is empty) ExperimentQueryParam.
+// Only experiments registered using RegisterExperiment are included
+// in the result, and an InvalidAssignment error is returned if a
+// request is assigned to an unknown variant.
+//
+// The result is cached in ctx.Data(), so it is safe to call this from
+// as many places as need it during a request.  Bind calls it when any
+// experiments are registered, and models can receive the assignments
+// in fields with the "experiment" source (see RegisterSource).
+func ParseAssignments(ctx context.Context) (Assignments, error) {
+	if assignments, ok := ctx.Data()[assignmentsDataKey].(Assignments); ok {
+		return assignments, nil
+	}
+	assignments, err := parseAssignments(ctx.HttpRequest())
+	if err != nil {
+		return nil, err
+	}
+	ctx.Data().Set(assignmentsDataKey, assignments)
+	return assignments, nil
+}
+
+// parseAssignments reads experiment assignments from request, without
+// caching them.
+func parseAssignments(request *http.Request) (Assignments, error) {
+	raw := request.Header.Get(ExperimentHeader)
+	if raw == "" {
+		raw = request.URL.Query().Get(ExperimentQueryParam)
+	}
+
+	assignments := make(Assignments)
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, variant, _ := strings.Cut(pair, "=")
+		name, variant = strings.TrimSpace(name), strings.TrimSpace(variant)
+		variants, ok := experiments[name]
+		if !ok {
+			continue
+		}
+		if !containsString(variants, variant) {
+			return nil, InvalidAssignment{Experiment: name, Variant: variant, Allowed: variants}
+		}
+		assignments[name] = variant
+	}
+	return assignments, nil
+}
+
+// containsString returns whether or not value is in values.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package web_request_readers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Radiobox/web_request_readers/readertest"
+)
+
+type testCheckout struct {
+	Total       int         `request:"total"`
+	Layout      string      `request:"checkout_layout,source=experiment,optional"`
+	Experiments Assignments `request:"experiments,source=experiment,optional"`
+}
+
+func TestBindReadsAssignments(t *testing.T) {
+	RegisterExperiment("checkout_layout", "control", "one_page")
+	defer delete(experiments, "checkout_layout")
+
+	bind := func(header string) (*readertest.Context, testCheckout, error) {
+		ctx := readertest.NewContext(readertest.JSONRequest(t, "POST", "/checkout", `{"total": 3}`))
+		ctx.Request.Header.Set(ExperimentHeader, header)
+		var checkout testCheckout
+		err := Bind(ctx, &checkout)
+		return ctx, checkout, err
+	}
+
+	ctx, checkout, err := bind("checkout_layout=one_page, retired=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkout.Layout != "one_page" || checkout.Experiments.Variant("checkout_layout") != "one_page" || len(checkout.Experiments) != 1 {
+		t.Errorf("unexpected assignments in %+v", checkout)
+	}
+	if _, cached := ctx.Data()[assignmentsDataKey].(Assignments); !cached {
+		t.Error("Bind didn't cache the assignments")
+	}
+
+	if _, checkout, err = bind(""); err != nil || checkout.Layout != "" || checkout.Total != 3 {
+		t.Errorf("unassigned request: %+v, %v", checkout, err)
+	}
+
+	var invalid InvalidAssignment
+	if _, _, err = bind("checkout_layout=nope"); !errors.As(err, &invalid) {
+		t.Errorf("expected an InvalidAssignment, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ExtraFields is an error type that stores a list of keys that were
+// sent in a request but didn't match any field of the model.
+type ExtraFields struct {
+	// Names stores the request keys that didn't match a field.
+	// Keys inside nested objects are named by their dotted path,
+	// e.g. "address.zip".
+	Names []string
+
+	// Field is the request key of the nested struct that the extra
+	// keys were sent for, or "" for the top-level model.
+	Field string
+}
+
+// Error returns the error message for an ExtraFields error.
+func (err ExtraFields) Error() string {
+	if err.Field != "" {
+		return fmt.Sprintf("More parameters passed for %s than its model has fields.", err.Field)
+	}
+	return "More parameters passed than this model has fields."
+}
+
+// AddExtraField adds a key that didn't match any field to the
+// ExtraFields error's list of extra fields.
+func (err *ExtraFields) AddExtraField(fieldName string) {
+	err.Names = append(err.Names, fieldName)
+}
+
+// HasExtraFields returns whether or not any extra keys were found in
+// a request.
+func (err ExtraFields) HasExtraFields() bool {
+	return len(err.Names) > 0
+}
+
+// extraFields returns an ExtraFields error listing the keys in params
+// that weren't consumed by any field, in sorted order.
+func (state *unmarshalState) extraFields(field string) ExtraFields {
+	err := ExtraFields{Field: field}
+	for key := range state.params {
+		if !state.consumed[key] {
+			err.AddExtraField(state.keyPath + key)
+		}
+	}
+	sort.Strings(err.Names)
+	return err
+}
@@ -0,0 +1,119 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// A FieldError is returned when the value for a single field in a
+// request couldn't be used, e.g. because it couldn't be converted to
+// the field's type.
+type FieldError struct {
+	// Field is the request key of the field.  For fields of
+	// nested structs, this is the dotted path of keys, e.g.
+	// "address.street".
+	Field string
+
+	// Code is a short, machine-readable description of what went
+	// wrong, e.g. "invalid" or "unit".
+	Code string
+
+	// Message is the human-readable error message.  It is
+	// rendered from a custom message template (see FieldMessages),
+	// or by the Unmarshaler's MessageProvider, or taken from Err.
+	Message string
+
+	// Err is the underlying error, if there is one.
+	Err error
+}
+
+// Error returns the error message for a FieldError.
+func (err FieldError) Error() string {
+	return err.Message
+}
+
+// Unwrap returns the underlying error.
+func (err FieldError) Unwrap() error {
+	return err.Err
+}
+
+// FieldErrors is a list of FieldErrors, returned when several fields
+// failed at once, e.g. several elements of a slice of structs.
+type FieldErrors []FieldError
+
+// Error returns the error message for a FieldErrors error.
+func (errs FieldErrors) Error() string {
+	messages := make([]string, len(errs))
+	for index, err := range errs {
+		messages[index] = err.Field + ": " + err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the individual FieldErrors, so that errors.As can
+// find them.
+func (errs FieldErrors) Unwrap() []error {
+	unwrapped := make([]error, len(errs))
+	for index, err := range errs {
+		unwrapped[index] = err
+	}
+	return unwrapped
+}
+
+// FieldMessages is a type that supplies custom error message
+// templates for its fields, so that user-facing APIs can return
+// friendly copy (e.g. "Please enter a valid email") straight from
+// UnmarshalParams.
+//
+// Messages are looked up by "key.code" first (e.g. "email.invalid"),
+// then by "key" alone.  A template may also be set with the msg tag
+// option, e.g. `request:"email,msg=Please enter a valid email"`,
+// which takes precedence over Messages.  Since tag options are
+// separated by commas, templates with commas must come from Messages.
+//
+// Templates may use the placeholders {field}, {code}, {value}, and
+// {error}, which are replaced with the request key, the error code,
+// the request value, and the underlying error message.
+type FieldMessages interface {
+	Messages() map[string]string
+}
+
+// fieldError builds a FieldError for a field, rendering a custom
+// message template if the field or target has one, or else asking the
+// unmarshaler's MessageProvider for a message.
+func (state *unmarshalState) fieldError(name string, args []string, code string, value interface{}, err error) error {
+	template, ok := tagOption(args, "msg")
+	if !ok {
+		template, ok = state.messages[name+"."+code]
+	}
+	if !ok {
+		template, ok = state.messages[name]
+	}
+	message := err.Error()
+	if ok {
+		message = renderMessage(template, name, code, value, err)
+	} else if provided := state.providedMessage(state.keyPath+name, args, code, value, err); provided != "" {
+		message = provided
+	}
+	state.logDebug("rejected request value",
+		slog.String("field", state.keyPath+name),
+		slog.String("code", code),
+		valueAttr(value),
+		errorAttr(err))
+	return FieldError{Field: state.keyPath + name, Code: code, Message: message, Err: err}
+}
+
+// renderMessage replaces the placeholders in a message template.
+func renderMessage(template, field, code string, value interface{}, err error) string {
+	var errMessage string
+	if err != nil {
+		errMessage = err.Error()
+	}
+	return strings.NewReplacer(
+		"{field}", field,
+		"{code}", code,
+		"{value}", fmt.Sprint(value),
+		"{error}", errMessage,
+	).Replace(template)
+}
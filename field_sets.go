@@ -0,0 +1,135 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stretchr/objx"
+)
+
+// FieldsParam is the name of the parameter that ParseFieldSet reads
+// field selections from.
+var FieldsParam = "fields"
+
+// A FieldSet is a parsed sparse fieldset, e.g. from
+// fields=id,name,owner.email.  Each key is a field name that was
+// selected, mapped to the set of its sub-fields that were selected.
+// A nil sub-set means the whole field was selected, and a nil
+// FieldSet means that no selection was made at all, so every field
+// should be included.
+type FieldSet map[string]FieldSet
+
+// ParseFieldSet reads a comma-separated list of (optionally dotted)
+// field names from the FieldsParam parameter.  The value may be a
+// string or a []string, in which case all of the values are combined.
+// If the parameter is missing, the returned FieldSet is nil.
+func ParseFieldSet(params objx.Map) (FieldSet, error) {
+	value, ok := params[FieldsParam]
+	if !ok {
+		return nil, nil
+	}
+	return fieldSetFromValue(value)
+}
+
+// ParseTypedFieldSets reads JSON:API style sparse fieldsets, e.g.
+// fields[articles]=title,body&fields[people]=name, returning a
+// FieldSet for each type that had a selection.
+func ParseTypedFieldSets(params objx.Map) (map[string]FieldSet, error) {
+	sets := make(map[string]FieldSet)
+	prefix := FieldsParam + "["
+	for key, value := range params {
+		if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		set, err := fieldSetFromValue(value)
+		if err != nil {
+			return nil, err
+		}
+		sets[key[len(prefix):len(key)-1]] = set
+	}
+	return sets, nil
+}
+
+func fieldSetFromValue(value interface{}) (FieldSet, error) {
+	var lists []string
+	switch src := value.(type) {
+	case string:
+		lists = []string{src}
+	case []string:
+		lists = src
+	default:
+		return nil, fmt.Errorf("Cannot read %s from value of type %T", FieldsParam, value)
+	}
+	set := make(FieldSet)
+	for _, list := range lists {
+		for _, path := range strings.Split(list, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				set.add(strings.Split(path, "."))
+			}
+		}
+	}
+	return set, nil
+}
+
+// add adds a path (already split on dots) to the set.
+func (set FieldSet) add(path []string) {
+	name := path[0]
+	child, exists := set[name]
+	if len(path) == 1 {
+		// Selecting the whole field overrides any sub-selection.
+		set[name] = nil
+		return
+	}
+	if exists && child == nil {
+		return
+	}
+	if child == nil {
+		child = make(FieldSet)
+		set[name] = child
+	}
+	child.add(path[1:])
+}
+
+// Has returns whether or not the (optionally dotted) path was
+// selected.  A field is selected if it, one of its parents, or one of
+// its sub-fields was named in the request.
+func (set FieldSet) Has(path string) bool {
+	if set == nil {
+		return true
+	}
+	name, rest, nested := strings.Cut(path, ".")
+	child, ok := set[name]
+	if !ok {
+		return false
+	}
+	if !nested {
+		return true
+	}
+	return child.Has(rest)
+}
+
+// Sub returns the selection for a field's sub-fields.  The result is
+// nil (i.e. everything is selected) if the whole field was selected
+// or if set itself is nil, and empty (i.e. nothing is selected) if
+// the field wasn't selected at all.
+func (set FieldSet) Sub(name string) FieldSet {
+	if set == nil {
+		return nil
+	}
+	child, ok := set[name]
+	if !ok {
+		return FieldSet{}
+	}
+	return child
+}
+
+// Fields returns the sorted names of the top-level fields in the set.
+func (set FieldSet) Fields() []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
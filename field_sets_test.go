@@ -0,0 +1,45 @@
+package web_request_readers
+
+import (
+	"testing"
+
+	"github.com/stretchr/objx"
+)
+
+func TestFieldSetNestedSelection(t *testing.T) {
+	set, err := ParseFieldSet(objx.Map{"fields": "a,c.d,e"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"a", true},
+		{"a.x", true},
+		{"b", false},
+		{"b.x", false},
+		{"c", true},
+		{"c.d", true},
+		{"c.x", false},
+		{"e", true},
+	}
+	for _, test := range tests {
+		if got := set.Has(test.path); got != test.want {
+			t.Errorf("Has(%q) = %v, want %v", test.path, got, test.want)
+		}
+	}
+
+	if sub := set.Sub("a"); sub != nil {
+		t.Errorf("Sub of a wholly selected field = %v, want nil", sub)
+	}
+	if sub := set.Sub("b"); sub == nil || sub.Has("x") {
+		t.Errorf("Sub of an unselected field = %v, want an empty set", sub)
+	}
+	if sub := set.Sub("c"); !sub.Has("d") || sub.Has("x") {
+		t.Errorf("Sub of a partly selected field = %v", sub)
+	}
+	if sub := FieldSet(nil).Sub("b"); sub != nil || !sub.Has("x") {
+		t.Errorf("Sub of no selection = %v, want nil", sub)
+	}
+}
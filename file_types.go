@@ -0,0 +1,136 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// sniffLen is the number of bytes that http.DetectContentType
+// considers when detecting a file's type.
+const sniffLen = 512
+
+var allowedFileTypes = make(map[string][]string)
+
+// SetAllowedFileTypes restricts the files uploaded under fieldName in
+// a multipart request to the passed in mime types.  A mime type may
+// use a wildcard subtype (e.g. "image/*") to allow an entire family of
+// types.  Calling SetAllowedFileTypes with no mime types removes any
+// restriction on the field.
+//
+// The type of each file is sniffed from its content using
+// http.DetectContentType; the Content-Type that the client sent for
+// the part is ignored, since there's no reason to trust it.
+//
+// The restrictions aren't guarded by a lock, so SetAllowedFileTypes
+// should be called before any requests are handled.
+func SetAllowedFileTypes(fieldName string, mimeTypes ...string) {
+	if len(mimeTypes) == 0 {
+		delete(allowedFileTypes, fieldName)
+		return
+	}
+	allowedFileTypes[fieldName] = mimeTypes
+}
+
+// AllowedFileTypes returns the mime types that files uploaded under
+// fieldName are restricted to, or nil if there is no restriction.
+func AllowedFileTypes(fieldName string) []string {
+	return allowedFileTypes[fieldName]
+}
+
+// FileTypeError is the error type returned when an uploaded file's
+// detected content type is not in the list of allowed types for its
+// field.
+type FileTypeError struct {
+	// Field is the name of the multipart field that the file was
+	// uploaded under.
+	Field string
+
+	// Filename is the name of the file, as sent by the client.
+	Filename string
+
+	// DetectedType is the mime type that was sniffed from the file's
+	// content.
+	DetectedType string
+
+	// Allowed is the list of mime types that were allowed for the
+	// field.
+	Allowed []string
+}
+
+// Error returns the error message for a FileTypeError.
+func (err FileTypeError) Error() string {
+	return fmt.Sprintf("File %s for field %s has type %s, but only %s are allowed",
+		err.Filename, err.Field, err.DetectedType, strings.Join(err.Allowed, ", "))
+}
+
+// ValidateFileTypes checks the detected content type of every file in
+// files against the types set using SetAllowedFileTypes.  The first
+// file that doesn't match will cause a FileTypeError to be returned.
+// ParseBody calls this for multipart requests, so you should only need
+// it if you're parsing multipart forms yourself.
+func ValidateFileTypes(files map[string][]*multipart.FileHeader) error {
+	for field, headers := range files {
+		allowed := AllowedFileTypes(field)
+		if allowed == nil {
+			continue
+		}
+		for _, header := range headers {
+			detected, err := DetectFileType(header)
+			if err != nil {
+				return err
+			}
+			if !mimeTypeAllowed(detected, allowed) {
+				return FileTypeError{
+					Field:        field,
+					Filename:     header.Filename,
+					DetectedType: detected,
+					Allowed:      allowed,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// DetectFileType sniffs the mime type of an uploaded file from its
+// content.  Any parameters (e.g. charset) are stripped from the
+// result.
+func DetectFileType(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	// A single Read may return less than the file has, so read until
+	// the buffer is full or the file ends.
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	detected := http.DetectContentType(buf[:n])
+	if mimeType, _, err := mime.ParseMediaType(detected); err == nil {
+		detected = mimeType
+	}
+	return detected, nil
+}
+
+// mimeTypeAllowed returns whether or not mimeType matches any of the
+// types in allowed, honoring wildcard subtypes.
+func mimeTypeAllowed(mimeType string, allowed []string) bool {
+	for _, allowedType := range allowed {
+		if allowedType == mimeType || allowedType == "*/*" {
+			return true
+		}
+		if strings.HasSuffix(allowedType, "/*") &&
+			strings.HasPrefix(mimeType, allowedType[:len(allowedType)-1]) {
+			return true
+		}
+	}
+	return false
+}
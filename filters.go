@@ -0,0 +1,120 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stretchr/objx"
+)
+
+// FilterParam is the name of the parameter that ParseFilters reads
+// filters from.  Form and query keys are expected to look like
+// filter[field] or filter[field][op]; JSON bodies may instead use a
+// nested object, e.g. {"filter": {"age": {"gte": 18}}}.
+var FilterParam = "filter"
+
+// DefaultFilterOp is the operator used for filters that don't specify
+// one, e.g. filter[status]=active.
+var DefaultFilterOp = "eq"
+
+// A Filter is a single condition parsed from a list request.
+type Filter struct {
+	Field string
+	Op    string
+	Value interface{}
+}
+
+// FilterRules maps the fields that may be filtered on to the
+// operators that are allowed for each of them.
+type FilterRules map[string][]string
+
+// InvalidFilter is the error type returned when a request filters on
+// a field that isn't in the FilterRules, or uses an operator that
+// isn't allowed for that field.
+type InvalidFilter struct {
+	Field string
+	Op    string
+
+	// Allowed is the list of operators allowed for Field, or nil
+	// if filtering on Field isn't allowed at all.
+	Allowed []string
+}
+
+// Error returns the error message for an InvalidFilter error.
+func (err InvalidFilter) Error() string {
+	if err.Allowed == nil {
+		return fmt.Sprintf("Cannot filter on field %s", err.Field)
+	}
+	return fmt.Sprintf("Cannot filter %s using %s; allowed operators are: %s",
+		err.Field, err.Op, strings.Join(err.Allowed, ","))
+}
+
+// ParseFilters reads filters out of params and checks them against
+// rules.  The returned filters are sorted by field and then operator,
+// so that the result doesn't depend on map ordering.
+func ParseFilters(params objx.Map, rules FilterRules) ([]Filter, error) {
+	var filters []Filter
+	if nested, ok := params[FilterParam].(objx.Map); ok {
+		for field, value := range nested {
+			if ops, ok := value.(objx.Map); ok {
+				for op, opValue := range ops {
+					filters = append(filters, Filter{Field: field, Op: op, Value: opValue})
+				}
+				continue
+			}
+			filters = append(filters, Filter{Field: field, Op: DefaultFilterOp, Value: value})
+		}
+	}
+
+	prefix := FilterParam + "["
+	for key, value := range params {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		parts, ok := splitBracketKey(key[len(FilterParam):])
+		if !ok || len(parts) == 0 || len(parts) > 2 {
+			return nil, fmt.Errorf("Malformed filter parameter %s", key)
+		}
+		filter := Filter{Field: parts[0], Op: DefaultFilterOp, Value: value}
+		if len(parts) == 2 {
+			filter.Op = parts[1]
+		}
+		filters = append(filters, filter)
+	}
+
+	for _, filter := range filters {
+		allowed, ok := rules[filter.Field]
+		if !ok {
+			return nil, InvalidFilter{Field: filter.Field, Op: filter.Op}
+		}
+		if !containsString(allowed, filter.Op) {
+			return nil, InvalidFilter{Field: filter.Field, Op: filter.Op, Allowed: allowed}
+		}
+	}
+	sort.Slice(filters, func(i, j int) bool {
+		if filters[i].Field != filters[j].Field {
+			return filters[i].Field < filters[j].Field
+		}
+		return filters[i].Op < filters[j].Op
+	})
+	return filters, nil
+}
+
+// splitBracketKey splits a string like "[a][b]" into its bracketed
+// parts.
+func splitBracketKey(key string) ([]string, bool) {
+	var parts []string
+	for key != "" {
+		if key[0] != '[' {
+			return nil, false
+		}
+		end := strings.IndexByte(key, ']')
+		if end == -1 {
+			return nil, false
+		}
+		parts = append(parts, key[1:end])
+		key = key[end+1:]
+	}
+	return parts, true
+}
@@ -0,0 +1,119 @@
+package web_request_readers
+
+import (
+	"strings"
+
+	"github.com/stretchr/objx"
+)
+
+// ForbiddenFields is an error type that stores a list of fields that
+// were sent in a request but may not be set from one, because they
+// are tagged with the "readonly" option, because they weren't in the
+// list passed to UnmarshalParamsAllowed, or because the caller doesn't
+// have their scope (see UnmarshalParamsScoped).  Forbidden fields are
+// never assigned.
+type ForbiddenFields struct {
+	// Names stores the request keys of the forbidden fields that
+	// were found in the request.
+	Names []string
+}
+
+// Error returns the error message for a ForbiddenFields error.
+func (err ForbiddenFields) Error() string {
+	return "Cannot set fields from a request: " + strings.Join(err.Names, ",")
+}
+
+// AddForbiddenField adds a name to the ForbiddenFields error's list of
+// forbidden fields.
+func (err *ForbiddenFields) AddForbiddenField(fieldName string) {
+	err.Names = append(err.Names, fieldName)
+}
+
+// HasForbiddenFields returns whether or not any forbidden fields were
+// found in a request.
+func (err ForbiddenFields) HasForbiddenFields() bool {
+	return len(err.Names) > 0
+}
+
+// UnmarshalParamsAllowed is like UnmarshalParams, but only the fields
+// whose request keys are in allowed may be set.  Fields of nested
+// structs are named by their dotted path, e.g. "address.street"; a
+// nested struct is allowed if it or any of its fields are.  If the
+// request contains values for any other fields, they are left alone
+// and the returned error is of type ForbiddenFields.  Fields that
+// aren't allowed are never reported as missing.
+func UnmarshalParamsAllowed(params objx.Map, target interface{}, allowed []string) error {
+	return DefaultUnmarshaler.UnmarshalParamsAllowed(params, target, allowed)
+}
+
+// UnmarshalParamsAllowed unmarshals the allowed fields of target
+// using the unmarshaler's options.  See the package-level
+// UnmarshalParamsAllowed for details.
+func (unmarshaler *Unmarshaler) UnmarshalParamsAllowed(params objx.Map, target interface{}, allowed []string) error {
+	state := unmarshaler.newState(nil, params)
+	defer state.release()
+	state.allowed = make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		state.allowed[name] = true
+	}
+	return unmarshaler.unmarshal(state, target)
+}
+
+// UnmarshalParamsScoped is like UnmarshalParams, but for a caller
+// with the given scopes (or roles).  Fields tagged with the "scope"
+// option, e.g.
+//
+//	Salary int `request:"salary,scope=admin|payroll"`
+//
+// may only be set by callers with at least one of the listed scopes.
+// Values for fields the caller can't set are reported as
+// ForbiddenFields, unless the Unmarshaler's SkipUnscopedFields option
+// is set, in which case they are silently ignored.  Scoped fields can
+// never be set by the other UnmarshalParams variants, since those
+// callers have no scopes.
+func UnmarshalParamsScoped(params objx.Map, target interface{}, scopes []string) error {
+	return DefaultUnmarshaler.UnmarshalParamsScoped(params, target, scopes)
+}
+
+// UnmarshalParamsScoped unmarshals params to target for a caller
+// with scopes, using the unmarshaler's options.  See the package-level
+// UnmarshalParamsScoped for details.
+func (unmarshaler *Unmarshaler) UnmarshalParamsScoped(params objx.Map, target interface{}, scopes []string) error {
+	state := unmarshaler.newState(nil, params)
+	defer state.release()
+	state.scopes = scopes
+	return unmarshaler.unmarshal(state, target)
+}
+
+// canSet returns whether a field may be set from the request.  path
+// is the dotted path of the field's request key.  If the field can't
+// be set, skip reports whether its value should be ignored rather
+// than reported as forbidden.
+func (state *unmarshalState) canSet(path string, args []string) (ok, skip bool) {
+	if containsString(args, "readonly") {
+		return false, false
+	}
+	if scopes, scoped := tagOption(args, "scope"); scoped && !state.hasScope(scopes) {
+		return false, state.unmarshaler.SkipUnscopedFields
+	}
+	if state.allowed == nil || state.allowed[path] {
+		return true, false
+	}
+	for name := range state.allowed {
+		if strings.HasPrefix(name, path+".") || strings.HasPrefix(path, name+".") {
+			return true, false
+		}
+	}
+	return false, false
+}
+
+// hasScope returns whether the caller has any of the scopes in a
+// scope tag option.
+func (state *unmarshalState) hasScope(scopes string) bool {
+	for _, scope := range strings.Split(scopes, "|") {
+		if containsString(state.scopes, scope) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package web_request_readers
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Radiobox/web_request_readers/readertest"
+)
+
+// The seed corpus for these targets is in testdata/fuzz; run them with
+// e.g. go test -fuzz=FuzzParseParams.
+
+func FuzzParseParams(f *testing.F) {
+	f.Add("application/json", `{"a":1}`)
+	f.Add("application/x-www-form-urlencoded", "a=1&a=2&b.c=3&d[e]=4")
+	f.Add("multipart/form-data; boundary=x", "--x\r\nContent-Disposition: form-data; name=\"a\"\r\n\r\n1\r\n--x--\r\n")
+	f.Add(JSONPatchType, `[{"op":"add","path":"/a","value":1}]`)
+	f.Fuzz(func(t *testing.T, contentType, body string) {
+		request := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		ctx := readertest.NewContext(request)
+		params, err := ParseParams(ctx)
+		if err != nil {
+			return
+		}
+		// A second call must return the cached result, not read
+		// the (now empty) body again.
+		again, err := ParseParams(ctx)
+		if err != nil || len(again) != len(params) {
+			t.Fatalf("cached params differ: %v, %v", again, err)
+		}
+	})
+}
+
+type fuzzAddress struct {
+	Street string   `request:"street,optional,maxlen=10"`
+	Zip    *int     `request:"zip,optional,min=0"`
+	Tags   []string `request:"tags,optional"`
+}
+
+type fuzzNode struct {
+	Name  string    `request:"name,optional"`
+	Child *fuzzNode `request:"child,optional"`
+}
+
+type fuzzModel struct {
+	Name     string            `request:"name,optional,trim"`
+	Age      int               `request:"age,optional,min=0,max=200"`
+	Score    float64           `request:"score,optional"`
+	OK       bool              `request:"ok,optional"`
+	Small    uint8             `request:"small,optional"`
+	Timeout  time.Duration     `request:"timeout,optional"`
+	When     time.Time         `request:"when,optional"`
+	Address  fuzzAddress       `request:"address,optional"`
+	Items    []fuzzAddress     `request:"items,optional"`
+	Node     *fuzzNode         `request:"node,optional"`
+	Any      interface{}       `request:"any,optional"`
+	Meta     map[string]string `request:"meta,optional"`
+	PtrSlice *[]string         `request:"ptr_slice,optional"`
+	Kind     string            `request:"kind,optional,enum=a|b"`
+	Other    string            `request:"other,optional,required_if=kind:a"`
+}
+
+func FuzzUnmarshalParams(f *testing.F) {
+	f.Add(`{"name":" x ","age":5,"address":{"street":"s","zip":3},"items":[{"street":"a"}]}`)
+	f.Add(`{"node":{"child":{"child":{"child":{}}}}}`)
+	f.Add(`{"age":"12","small":300,"timeout":"5s","when":"2020-01-01T00:00:00Z"}`)
+	f.Fuzz(func(t *testing.T, body string) {
+		ctx := readertest.NewContext(readertest.JSONRequest(t, "POST", "/", body))
+		params, err := ParseParams(ctx)
+		if err != nil {
+			return
+		}
+		unmarshalers := []*Unmarshaler{
+			DefaultUnmarshaler,
+			{Patch: true},
+			{NumericKeySlices: true, Coercions: WeakTyping},
+		}
+		for _, unmarshaler := range unmarshalers {
+			var model fuzzModel
+			err := unmarshaler.UnmarshalRequestParams(ctx, params, &model)
+			var invalidTarget InvalidTargetError
+			if errors.As(err, &invalidTarget) {
+				t.Fatalf("valid target rejected: %s", err)
+			}
+		}
+		var node fuzzNode
+		UnmarshalParams(params, &node)
+	})
+}
@@ -0,0 +1,86 @@
+package web_request_readers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/stretchr/goweb/context"
+	"github.com/stretchr/objx"
+)
+
+// A GraphQLRequest is a GraphQL-over-HTTP request, e.g.
+//
+//	{"query": "mutation($input: UserInput!) {...}", "variables": {"input": {...}}}
+//
+// Its Variables (or one of them, with Input) can be passed straight to
+// UnmarshalParams, so the same models can serve REST and GraphQL
+// inputs.
+type GraphQLRequest struct {
+	Query         string
+	OperationName string
+	Variables     objx.Map
+}
+
+// ParseGraphQLRequest reads a GraphQL request from the request body,
+// or from the query string for GET requests.  Variables may be a JSON
+// object or, as they usually are in a query string, a string
+// containing a JSON object.  A request without variables gets an empty
+// Variables map.
+func ParseGraphQLRequest(ctx context.Context) (*GraphQLRequest, error) {
+	params, err := ParseParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return GraphQLRequestFromParams(params)
+}
+
+// GraphQLRequestFromParams reads a GraphQL request from params that
+// have already been parsed.
+func GraphQLRequestFromParams(params objx.Map) (*GraphQLRequest, error) {
+	request := &GraphQLRequest{Variables: make(objx.Map)}
+	var ok bool
+	if request.Query, ok = params["query"].(string); !ok {
+		return nil, errors.New("GraphQL request has no query")
+	}
+	if name, exists := params["operationName"]; exists && name != nil {
+		if request.OperationName, ok = name.(string); !ok {
+			return nil, errors.New("GraphQL operationName must be a string")
+		}
+	}
+	switch variables := params["variables"].(type) {
+	case nil:
+	case objx.Map:
+		request.Variables = variables
+	case map[string]interface{}:
+		request.Variables = objx.Map(variables)
+	case string:
+		if variables == "" {
+			break
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(variables), &decoded); err != nil {
+			return nil, fmt.Errorf("Cannot parse GraphQL variables: %s", err)
+		}
+		request.Variables = ConvertMSIToObjxMap(decoded).(objx.Map)
+	default:
+		return nil, fmt.Errorf("GraphQL variables must be an object, not %s", jsonTypeName(variables))
+	}
+	return request, nil
+}
+
+// Input returns the variable name as params, for the common pattern of
+// passing a mutation's arguments as a single input object.
+func (request *GraphQLRequest) Input(name string) (objx.Map, error) {
+	value, ok := request.Variables[name]
+	if !ok {
+		var missing MissingFields
+		missing.AddMissingField(name)
+		return nil, missing
+	}
+	input, ok := asParams(value)
+	if !ok {
+		return nil, fmt.Errorf("GraphQL variable %s must be an object, not %s", name, jsonTypeName(value))
+	}
+	return input, nil
+}
@@ -0,0 +1,71 @@
+package web_request_readers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/stretchr/goweb/context"
+)
+
+// A BindErrorResponder writes the response for a request that
+// BindHandler couldn't bind.  The error it returns is returned to
+// goweb from the handler.
+type BindErrorResponder func(ctx context.Context, err error) error
+
+// SetBindErrorResponder atomically changes the function BindHandler
+// uses to respond to requests that fail to bind.  Passing nil
+// restores RespondWithBindError.
+func SetBindErrorResponder(responder BindErrorResponder) {
+	updateConfig(func(c *Config) { c.BindErrorResponder = responder })
+}
+
+// BindHandler wraps a handler that takes a bound model as a goweb
+// handler, e.g.
+//
+//	goweb.Map("POST", "users", web_request_readers.BindHandler(createUser))
+//
+//	func createUser(ctx context.Context, user *User) error {
+//	    ...
+//	}
+//
+// Each request is bound to a new T with Bind, which parses,
+// unmarshals, and validates it.  If that fails, handler isn't called,
+// and the request is answered by the BindErrorResponder (see
+// SetBindErrorResponder), which by default is RespondWithBindError.
+func BindHandler[T any](handler func(ctx context.Context, model *T) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		model := new(T)
+		if err := Bind(ctx, model); err != nil {
+			respond := CurrentConfig().BindErrorResponder
+			if respond == nil {
+				respond = RespondWithBindError
+			}
+			return respond(ctx, err)
+		}
+		return handler(ctx, model)
+	}
+}
+
+// RespondWithBindError writes a JSON response for err, with the
+// status from SuggestedStatus.  Errors that have an ErrorResponse
+// (see ResponseFor) are written as one; anything else is written as
+// an ErrorResponse with a single entry holding err's message, except
+// that internal errors (status 500) get a generic message, so they
+// don't leak details about the server.  ResponseHeaders are written
+// first.
+func RespondWithBindError(ctx context.Context, err error) error {
+	status := SuggestedStatus(err)
+	response, ok := ResponseFor(err)
+	if !ok || status == http.StatusInternalServerError {
+		message := err.Error()
+		if status == http.StatusInternalServerError {
+			message = http.StatusText(status)
+		}
+		response = ErrorResponse{Errors: []ResponseError{{Code: "invalid", Message: message}}}
+	}
+	WriteResponseHeaders(ctx)
+	writer := ctx.HttpResponseWriter()
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(status)
+	return json.NewEncoder(writer).Encode(response)
+}
@@ -0,0 +1,69 @@
+package web_request_readers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/stretchr/objx"
+)
+
+// SpamDetected is the error returned by ParseBody and ParseParams when
+// a honeypot field (see Options.HoneypotFields) arrives with a value.
+// Handlers of public forms often answer it as if the submission had
+// succeeded, so that bots don't learn to avoid the trap.
+type SpamDetected struct {
+	// Fields are the honeypot fields that had values.
+	Fields []string
+}
+
+// Error returns the error message for a SpamDetected error.
+func (err SpamDetected) Error() string {
+	return "Spam detected in fields: " + strings.Join(err.Fields, ", ")
+}
+
+// StatusCode returns 400 Bad Request.
+func (err SpamDetected) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// checkHoneypots returns a SpamDetected error if any of fields has a
+// value in params.  Empty honeypot fields are removed from params, so
+// that models don't need fields for them and they aren't reported in
+// ExtraFields.
+func checkHoneypots(params objx.Map, fields []string) error {
+	var filled []string
+	for _, field := range fields {
+		value, ok := params[field]
+		if !ok {
+			continue
+		}
+		if honeypotEmpty(value) {
+			delete(params, field)
+			continue
+		}
+		filled = append(filled, field)
+	}
+	if len(filled) > 0 {
+		return SpamDetected{Fields: filled}
+	}
+	return nil
+}
+
+// honeypotEmpty returns whether value is what a person would leave in
+// a hidden field: nothing.
+func honeypotEmpty(value interface{}) bool {
+	switch src := value.(type) {
+	case nil:
+		return true
+	case string:
+		return src == ""
+	case []string:
+		for _, val := range src {
+			if val != "" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package web_request_readers
+
+import (
+	"io"
+	"time"
+)
+
+// A BodyParseHook is called by ParseBody before it decodes a request
+// body, with the media type from the request's Content-Type header.
+type BodyParseHook func(mime string)
+
+// A BodyParsedHook is called by ParseBody after it has decoded (or
+// failed to decode) a request body, with the media type from the
+// request's Content-Type header, the number of bytes read from the
+// body, how long decoding took, and the error, if any.
+type BodyParsedHook func(mime string, size int, dur time.Duration, err error)
+
+// An UnmarshalledHook is called after params have been unmarshalled
+// to target by UnmarshalParams (or any of its variants, or
+// UnmarshalSlice), with how long it took and the error, if any.
+// Nested structs and the elements of UnmarshalSlice don't get their
+// own calls.
+type UnmarshalledHook func(target interface{}, dur time.Duration, err error)
+
+var (
+	bodyParseHooks    []BodyParseHook
+	bodyParsedHooks   []BodyParsedHook
+	unmarshalledHooks []UnmarshalledHook
+)
+
+// OnBodyParse adds a hook to be called before each request body is
+// decoded.  Hooks are not called for requests whose body was already
+// parsed.  Like AddBindCheck, OnBodyParse is not safe to call while
+// requests are being handled, so hooks should be added during
+// initialization.
+func OnBodyParse(hook BodyParseHook) {
+	bodyParseHooks = append(bodyParseHooks, hook)
+}
+
+// OnBodyParsed adds a hook to be called after each request body is
+// decoded, e.g. to record metrics about payload sizes, decode latency,
+// and failures per content type.  Hooks are not called for requests
+// whose body was already parsed.  Like AddBindCheck, OnBodyParsed is
+// not safe to call while requests are being handled, so hooks should
+// be added during initialization.
+func OnBodyParsed(hook BodyParsedHook) {
+	bodyParsedHooks = append(bodyParsedHooks, hook)
+}
+
+// OnUnmarshalled adds a hook to be called after each unmarshal, e.g.
+// to count errors by type and field.  Like AddBindCheck,
+// OnUnmarshalled is not safe to call while requests are being
+// handled, so hooks should be added during initialization.
+func OnUnmarshalled(hook UnmarshalledHook) {
+	unmarshalledHooks = append(unmarshalledHooks, hook)
+}
+
+// runUnmarshalledHooks calls the UnmarshalledHooks for an unmarshal
+// to target that started at start.
+func runUnmarshalledHooks(target interface{}, start time.Time, err error) {
+	duration := time.Since(start)
+	for _, hook := range unmarshalledHooks {
+		hook(target, duration, err)
+	}
+}
+
+// countingReader counts the bytes read from a request body.
+type countingReader struct {
+	io.ReadCloser
+	count int
+}
+
+func (reader *countingReader) Read(p []byte) (int, error) {
+	n, err := reader.ReadCloser.Read(p)
+	reader.count += n
+	return n, err
+}
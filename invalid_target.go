@@ -0,0 +1,73 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// InvalidTargetError is an error type for a target that can't be
+// unmarshalled to, such as a struct passed by value or a nil pointer.
+// It is returned before anything is read from the request, in place
+// of the panic that reflect would otherwise raise.
+type InvalidTargetError struct {
+	// Func is the name of the function that was called, e.g.
+	// "UnmarshalParams", and Expected describes the targets it
+	// accepts.
+	Func     string
+	Expected string
+
+	// Type is the type of the target that was passed, or nil if
+	// the target was nil.  Kind is its kind, or reflect.Invalid.
+	Type reflect.Type
+	Kind reflect.Kind
+
+	// Nil is true if the target was a nil pointer.
+	Nil bool
+}
+
+// Error returns the error message for an InvalidTargetError error.
+func (err InvalidTargetError) Error() string {
+	switch {
+	case err.Type == nil:
+		return fmt.Sprintf("%s target must be %s, not nil", err.Func, err.Expected)
+	case err.Nil:
+		return fmt.Sprintf("%s target must be %s, not a nil %s", err.Func, err.Expected, err.Type)
+	}
+	if err.Kind == reflect.Ptr {
+		return fmt.Sprintf("%s target must be %s, not %s (a pointer to kind %s)", err.Func, err.Expected, err.Type, err.Type.Elem().Kind())
+	}
+	return fmt.Sprintf("%s target must be %s, not %s (kind %s)", err.Func, err.Expected, err.Type, err.Kind)
+}
+
+// invalidTarget returns an InvalidTargetError describing target.
+func invalidTarget(funcName, expected string, target interface{}) InvalidTargetError {
+	err := InvalidTargetError{Func: funcName, Expected: expected}
+	if target == nil {
+		return err
+	}
+	value := reflect.ValueOf(target)
+	err.Type, err.Kind = value.Type(), value.Kind()
+	err.Nil = value.Kind() == reflect.Ptr && value.IsNil()
+	return err
+}
+
+// checkTarget returns an InvalidTargetError if target is not a
+// non-nil pointer to a struct.  Pointers to other types are allowed
+// if they are Unmarshallers, since those never need reflection.
+func checkTarget(target interface{}) error {
+	const expected = "a non-nil pointer to a struct"
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return invalidTarget("UnmarshalParams", expected, target)
+	}
+	if value.Elem().Kind() == reflect.Struct {
+		return nil
+	}
+	if _, ok := target.(Unmarshaller); ok {
+		return nil
+	}
+	if _, ok := value.Elem().Interface().(Unmarshaller); ok {
+		return nil
+	}
+	return invalidTarget("UnmarshalParams", expected, target)
+}
@@ -0,0 +1,168 @@
+// Package jsonapi reads JSON:API (https://jsonapi.org) request
+// documents into the flat params that web_request_readers'
+// UnmarshalParams expects.  A document like
+//
+//	{"data": {
+//	    "type": "articles",
+//	    "id": "1",
+//	    "attributes": {"title": "Rails is Omakase"},
+//	    "relationships": {
+//	        "author": {"data": {"type": "people", "id": "9"}},
+//	        "tags":   {"data": [{"type": "tags", "id": "2"}]}
+//	    }
+//	}}
+//
+// becomes
+//
+//	{"id": "1", "title": "Rails is Omakase", "author_id": "9", "tags_ids": ["2"]}
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+
+	web_request_readers "github.com/Radiobox/web_request_readers"
+	"github.com/stretchr/goweb/context"
+	"github.com/stretchr/objx"
+)
+
+// MediaType is the JSON:API media type.
+const MediaType = "application/vnd.api+json"
+
+// IDKey is the params key that a resource's id is stored under.  If
+// it is empty, the id is left out.
+var IDKey = "id"
+
+// TypeKey is the params key that a resource's type is stored under.
+// If it is empty (the default), the type is left out.
+var TypeKey = ""
+
+// RelationshipKey returns the params key that the ids of a
+// relationship are stored under.  By default, to-one relationships
+// use name + "_id" and to-many relationships use name + "_ids".
+var RelationshipKey = func(name string, toMany bool) string {
+	if toMany {
+		return name + "_ids"
+	}
+	return name + "_id"
+}
+
+// ParseParams reads a JSON:API request body and returns its primary
+// resource as flat params (see Flatten).  The body is read with
+// web_request_readers.ReadJSONBody, so it is subject to the same
+// request Options and configuration as other bodies.  The params are
+// cached in ctx.Data() the same way web_request_readers.ParseParams
+// caches them, so Bind and ParseParams return them for the rest of the
+// request, and they are checked for Options.HoneypotFields on every
+// call.
+func ParseParams(ctx context.Context) (objx.Map, error) {
+	if cached, ok := web_request_readers.CachedParams(ctx); ok {
+		if _, ok := cached.(objx.Map); ok {
+			return web_request_readers.ParseParams(ctx)
+		}
+	}
+	request := ctx.HttpRequest()
+	mimeType, _, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	if mimeType != MediaType {
+		return nil, web_request_readers.UnsupportedMediaType{MimeType: mimeType, Supported: []string{MediaType}}
+	}
+	doc, err := web_request_readers.ReadJSONBody(ctx)
+	if err != nil {
+		return nil, err
+	}
+	params, err := Flatten(doc)
+	if err != nil {
+		return nil, err
+	}
+	web_request_readers.CacheParams(ctx, params)
+	return web_request_readers.ParseParams(ctx)
+}
+
+// Flatten converts a decoded JSON:API document to flat params.  The
+// document's primary data must be a single resource object; its
+// attributes are copied as they are, and each relationship's resource
+// identifiers are replaced with their ids, stored under
+// RelationshipKey.  An empty to-one relationship (null data) is stored
+// as nil.
+func Flatten(doc interface{}) (objx.Map, error) {
+	docMap, ok := asMap(doc)
+	if !ok {
+		return nil, errors.New("A JSON:API document must be an object")
+	}
+	data, ok := asMap(docMap["data"])
+	if !ok {
+		return nil, errors.New("A JSON:API document's data must be a single resource object")
+	}
+
+	params := make(objx.Map)
+	if attributes, ok := asMap(data["attributes"]); ok {
+		for key, value := range attributes {
+			params[key] = web_request_readers.ConvertMSIToObjxMap(value)
+		}
+	} else if data["attributes"] != nil {
+		return nil, errors.New("A JSON:API resource's attributes must be an object")
+	}
+	if id, ok := data["id"]; ok && IDKey != "" {
+		params[IDKey] = id
+	}
+	if resourceType, ok := data["type"]; ok && TypeKey != "" {
+		params[TypeKey] = resourceType
+	}
+
+	relationships, _ := asMap(data["relationships"])
+	for name, value := range relationships {
+		relationship, ok := asMap(value)
+		if !ok {
+			return nil, fmt.Errorf("Relationship %s must be an object", name)
+		}
+		switch linkage := relationship["data"].(type) {
+		case nil:
+			params[RelationshipKey(name, false)] = nil
+		case []interface{}:
+			ids := make([]interface{}, len(linkage))
+			for index, identifier := range linkage {
+				id, err := identifierID(name, identifier)
+				if err != nil {
+					return nil, err
+				}
+				ids[index] = id
+			}
+			params[RelationshipKey(name, true)] = ids
+		default:
+			id, err := identifierID(name, linkage)
+			if err != nil {
+				return nil, err
+			}
+			params[RelationshipKey(name, false)] = id
+		}
+	}
+	return params, nil
+}
+
+// identifierID returns the id of a resource identifier object.
+func identifierID(relationship string, identifier interface{}) (interface{}, error) {
+	identifierMap, ok := asMap(identifier)
+	if !ok {
+		return nil, fmt.Errorf("Relationship %s has an invalid resource identifier", relationship)
+	}
+	id, ok := identifierMap["id"]
+	if !ok {
+		return nil, fmt.Errorf("Relationship %s has a resource identifier without an id", relationship)
+	}
+	return id, nil
+}
+
+// asMap returns value as a map, if it is a JSON object.
+func asMap(value interface{}) (map[string]interface{}, bool) {
+	switch src := value.(type) {
+	case map[string]interface{}:
+		return src, true
+	case objx.Map:
+		return src, true
+	}
+	return nil, false
+}
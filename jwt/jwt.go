@@ -0,0 +1,204 @@
+// Package jwt validates the JSON Web Token in a request's
+// Authorization header and registers its claims as a
+// web_request_readers value source, so that fields can bind the
+// authenticated subject (or any other claim) straight into a model:
+//
+//	jwt.Install(&jwt.Verifier{
+//		KeyFunc: func(token *gojwt.Token) (interface{}, error) {
+//			return signingKey, nil
+//		},
+//		ValidMethods: []string{"HS256"},
+//	})
+//
+//	type CreatePost struct {
+//		AuthorID string `request:"author_id,source=claims,name=sub"`
+//		Title    string `request:"title"`
+//	}
+//
+// Claims are looked up as objx paths, so nested claims can be read
+// with dotted names, e.g. name=org.id.  A request without a bearer
+// token has no claims, so required claim fields are reported as
+// missing; a token that fails validation is reported as an
+// InvalidToken, with status 401.
+package jwt
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	web_request_readers "github.com/Radiobox/web_request_readers"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/objx"
+)
+
+// SourceName is the name of the value source that Install registers,
+// as used in source=claims.
+const SourceName = "claims"
+
+// InvalidToken is the error returned for a bearer token that isn't a
+// valid JWT, e.g. because its signature doesn't match or it has
+// expired.
+type InvalidToken struct {
+	Err error
+}
+
+// Error returns the error message for an InvalidToken error.
+func (err InvalidToken) Error() string {
+	return "Invalid bearer token: " + err.Err.Error()
+}
+
+// Unwrap returns the validation error, e.g. gojwt.ErrTokenExpired.
+func (err InvalidToken) Unwrap() error {
+	return err.Err
+}
+
+// StatusCode returns 401 Unauthorized.
+func (err InvalidToken) StatusCode() int {
+	return http.StatusUnauthorized
+}
+
+// A Verifier validates JWTs.
+type Verifier struct {
+	// KeyFunc returns the key to verify a token's signature with,
+	// as for gojwt.Parse.  It is required.
+	KeyFunc gojwt.Keyfunc
+
+	// ValidMethods are the signing methods that tokens may use, e.g.
+	// []string{"RS256"}.  If it is empty, the standard HMAC, RSA,
+	// RSA-PSS, ECDSA, and EdDSA methods are allowed, so that a
+	// method registered by some other package can't be used to
+	// forge tokens.
+	ValidMethods []string
+
+	// Options are passed to the parser after ValidMethods, e.g. to
+	// require an audience.  A gojwt.WithValidMethods option here
+	// overrides ValidMethods.
+	Options []gojwt.ParserOption
+}
+
+// standardMethods are the signing methods allowed by a Verifier that
+// doesn't restrict them itself.
+var standardMethods = []string{
+	"HS256", "HS384", "HS512",
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// parserOptions returns the options to parse tokens with.
+func (verifier *Verifier) parserOptions() []gojwt.ParserOption {
+	methods := verifier.ValidMethods
+	if len(methods) == 0 {
+		methods = standardMethods
+	}
+	options := make([]gojwt.ParserOption, 0, len(verifier.Options)+1)
+	options = append(options, gojwt.WithValidMethods(methods))
+	return append(options, verifier.Options...)
+}
+
+// Verify validates the bearer token in r's Authorization header and
+// returns its claims.  The second return value is false if r doesn't
+// have a bearer token.
+func (verifier *Verifier) Verify(r *http.Request) (objx.Map, bool, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, false, nil
+	}
+	credentials, err := web_request_readers.ParseAuthorization(header)
+	if err != nil || credentials.Scheme != web_request_readers.BearerScheme {
+		return nil, false, err
+	}
+	claims := gojwt.MapClaims{}
+	if _, err := gojwt.ParseWithClaims(credentials.Token, claims, verifier.KeyFunc, verifier.parserOptions()...); err != nil {
+		return nil, true, InvalidToken{Err: err}
+	}
+	return web_request_readers.ConvertMSIToObjxMap(map[string]interface{}(claims)).(objx.Map), true, nil
+}
+
+// claimsKey is the context.Context key for verified claims.
+type claimsKey struct{}
+
+// WithClaims returns a copy of ctx carrying claims that have already
+// been verified, e.g. by authentication middleware, so that the
+// claims source doesn't verify the token again.
+func WithClaims(ctx context.Context, claims objx.Map) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFrom returns the claims attached to ctx with WithClaims.
+func ClaimsFrom(ctx context.Context) (objx.Map, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(objx.Map)
+	return claims, ok
+}
+
+// verified is the outcome of verifying a request's bearer token.
+type verified struct {
+	claims objx.Map
+	ok     bool
+	err    error
+}
+
+// memo holds the outcome of verifying each request that is being
+// bound, so that a model with several claims fields verifies its
+// token once.  Entries are removed when their request's context is
+// done; requests whose context can never be done aren't memoized,
+// since their entries would never be removed.
+type memo struct {
+	mu       sync.Mutex
+	requests map[*http.Request]verified
+}
+
+// verify returns the memoized outcome of verifying r, verifying it
+// with verifier the first time.
+func (memo *memo) verify(verifier *Verifier, r *http.Request) (objx.Map, bool, error) {
+	if r.Context().Done() == nil {
+		return verifier.Verify(r)
+	}
+	memo.mu.Lock()
+	result, ok := memo.requests[r]
+	memo.mu.Unlock()
+	if ok {
+		return result.claims, result.ok, result.err
+	}
+
+	// Fields of one request are read in turn, so the token isn't
+	// verified under the lock, where it would hold up other requests.
+	result.claims, result.ok, result.err = verifier.Verify(r)
+	memo.mu.Lock()
+	if memo.requests == nil {
+		memo.requests = make(map[*http.Request]verified)
+	}
+	memo.requests[r] = result
+	memo.mu.Unlock()
+	context.AfterFunc(r.Context(), func() {
+		memo.mu.Lock()
+		defer memo.mu.Unlock()
+		delete(memo.requests, r)
+	})
+	return result.claims, result.ok, result.err
+}
+
+// Install registers the claims value source, which reads claims that
+// were attached to the request's context with WithClaims, or else
+// verifies the request's bearer token with verifier.  The token is
+// verified once per request, however many fields read its claims.
+// Like web_request_readers.RegisterSource, it should be called before
+// any requests are handled.
+func Install(verifier *Verifier) {
+	memo := &memo{}
+	web_request_readers.RegisterSource(SourceName, func(r *http.Request, key string) (interface{}, bool, error) {
+		claims, ok := ClaimsFrom(r.Context())
+		if !ok {
+			var err error
+			if claims, ok, err = memo.verify(verifier, r); err != nil || !ok {
+				return nil, false, err
+			}
+		}
+		if !claims.Has(key) {
+			return nil, false, nil
+		}
+		return claims.Get(key).Data(), true, nil
+	})
+}
@@ -0,0 +1,86 @@
+package web_request_readers
+
+import (
+	"strings"
+)
+
+// A KeyMatcher decides which request keys a struct field may be read
+// from, when the field doesn't have a tag that names its key.
+type KeyMatcher interface {
+	// Keys returns the request keys for a field named fieldName,
+	// in order of preference.  The first key that is present in a
+	// request wins, and the first key is the one reported in
+	// MissingFields.
+	Keys(fieldName string) []string
+}
+
+// A KeyMatcherFunc is a function that acts as a KeyMatcher, for
+// custom matching rules.
+type KeyMatcherFunc func(fieldName string) []string
+
+// Keys calls matcher(fieldName).
+func (matcher KeyMatcherFunc) Keys(fieldName string) []string {
+	return matcher(fieldName)
+}
+
+// caseFolder is implemented by KeyMatchers whose keys should be
+// compared without regard to case.
+type caseFolder interface {
+	foldsCase() bool
+}
+
+var (
+	// LowerKeys matches a field named FirstName to the key
+	// "firstname".  This is the default.
+	LowerKeys KeyMatcher = KeyMatcherFunc(func(fieldName string) []string {
+		return []string{strings.ToLower(fieldName)}
+	})
+
+	// ExactKeys matches a field named FirstName to the key
+	// "FirstName", and nothing else.
+	ExactKeys KeyMatcher = KeyMatcherFunc(func(fieldName string) []string {
+		return []string{fieldName}
+	})
+
+	// SnakeKeys matches a field named FirstName to the key
+	// "first_name".
+	SnakeKeys KeyMatcher = KeyMatcherFunc(func(fieldName string) []string {
+		return []string{SnakeKey(fieldName)}
+	})
+
+	// CamelKeys matches a field named FirstName to the key
+	// "firstName".
+	CamelKeys KeyMatcher = KeyMatcherFunc(func(fieldName string) []string {
+		return []string{CamelKey(fieldName)}
+	})
+
+	// CaseInsensitiveKeys matches a field named FirstName to any
+	// key that is equal to "FirstName" or "first_name" regardless
+	// of case, e.g. "firstName", "FIRSTNAME", or "First_Name".
+	CaseInsensitiveKeys KeyMatcher = caseInsensitiveKeys{}
+)
+
+type caseInsensitiveKeys struct{}
+
+func (caseInsensitiveKeys) Keys(fieldName string) []string {
+	return []string{fieldName, SnakeKey(fieldName)}
+}
+
+func (caseInsensitiveKeys) foldsCase() bool {
+	return true
+}
+
+// untaggedKeys returns the request keys for an untagged field, and
+// whether they should be compared without regard to case.
+func (unmarshaler *Unmarshaler) untaggedKeys(fieldName string) ([]string, bool) {
+	matcher := unmarshaler.KeyMatcher
+	if matcher == nil {
+		matcher = LowerKeys
+	}
+	keys := matcher.Keys(fieldName)
+	if len(keys) == 0 {
+		keys = []string{strings.ToLower(fieldName)}
+	}
+	folder, ok := matcher.(caseFolder)
+	return keys, ok && folder.foldsCase()
+}
@@ -0,0 +1,95 @@
+package web_request_readers
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A KeyNormalizer is a single step in an Unmarshaler's key
+// normalization pipeline.  It takes a key (either from a request or
+// from a struct field) and returns the normalized version of it.
+type KeyNormalizer func(key string) string
+
+// TrimKey removes leading and trailing whitespace from a key.
+func TrimKey(key string) string {
+	return strings.TrimSpace(key)
+}
+
+// FoldKey converts a key to lower case, so that keys match without
+// regard to case.
+func FoldKey(key string) string {
+	return strings.ToLower(key)
+}
+
+// SnakeKey converts a camelCase, PascalCase, or kebab-case key to
+// snake_case.  Runs of upper case letters are treated as a single
+// word, so "UserID" becomes "user_id".
+func SnakeKey(key string) string {
+	runes := []rune(key)
+	out := make([]rune, 0, len(runes)+4)
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ':
+			out = append(out, '_')
+		case unicode.IsUpper(r):
+			startsWord := i > 0 && runes[i-1] != '_' && runes[i-1] != '-' &&
+				(unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+					(i+1 < len(runes) && unicode.IsLower(runes[i+1])))
+			if startsWord {
+				out = append(out, '_')
+			}
+			out = append(out, unicode.ToLower(r))
+		default:
+			out = append(out, r)
+		}
+	}
+	return string(out)
+}
+
+// CamelKey converts a snake_case or kebab-case key to camelCase.
+func CamelKey(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return r == '_' || r == '-' || r == ' '
+	})
+	for i, word := range words {
+		runes := []rune(word)
+		if i == 0 {
+			runes[0] = unicode.ToLower(runes[0])
+		} else {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		words[i] = string(runes)
+	}
+	return strings.Join(words, "")
+}
+
+// StripKeyPrefix returns a KeyNormalizer that removes prefix from the
+// start of a key, e.g. StripKeyPrefix("X-") for clients that insist
+// on prefixing their keys.  Keys without the prefix are unchanged.
+func StripKeyPrefix(prefix string) KeyNormalizer {
+	return func(key string) string {
+		return strings.TrimPrefix(key, prefix)
+	}
+}
+
+// ReplaceKeySeparator returns a KeyNormalizer that replaces every old
+// separator in a key with new, e.g. ReplaceKeySeparator(".", "_") for
+// dotted vendor keys.
+func ReplaceKeySeparator(old, new string) KeyNormalizer {
+	return func(key string) string {
+		return strings.Replace(key, old, new, -1)
+	}
+}
+
+// KeyAliases returns a KeyNormalizer that maps keys to their
+// canonical names.  Keys that aren't in aliases are unchanged.  Since
+// the pipeline runs in order, aliases should be written in terms of
+// the output of any earlier steps.
+func KeyAliases(aliases map[string]string) KeyNormalizer {
+	return func(key string) string {
+		if canonical, ok := aliases[key]; ok {
+			return canonical
+		}
+		return key
+	}
+}
@@ -0,0 +1,78 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+)
+
+// checkLimits checks a field's value, after it has been set, against
+// its min, max, minlen, and maxlen tag options, e.g.
+//
+//	Age  int      `request:"age,min=13,max=130"`
+//	Tags []string `request:"tags,minlen=1,maxlen=10"`
+//
+// min and max apply to numeric fields.  minlen and maxlen apply to
+// strings (counted in characters), slices, and maps.  Pointer fields
+// are checked using the value they point to; nil pointers are never
+// checked.  The returned code is the name of the option that failed.
+func checkLimits(field reflect.Value, args []string) (code string, err error) {
+	for field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return "", nil
+		}
+		field = field.Elem()
+	}
+
+	var num float64
+	isNumber := true
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		num = float64(field.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		num = float64(field.Uint())
+	case reflect.Float32, reflect.Float64:
+		num = field.Float()
+	default:
+		isNumber = false
+	}
+
+	length, unit := -1, "items"
+	switch field.Kind() {
+	case reflect.String:
+		length, unit = utf8.RuneCountInString(field.String()), "characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		length = field.Len()
+	}
+
+	for _, option := range []string{"min", "max", "minlen", "maxlen"} {
+		limitStr, ok := tagOption(args, option)
+		if !ok {
+			continue
+		}
+		limit, err := strconv.ParseFloat(limitStr, 64)
+		if err != nil {
+			return option, fmt.Errorf("Invalid %s option %s", option, limitStr)
+		}
+		switch option {
+		case "min":
+			if isNumber && num < limit {
+				return option, fmt.Errorf("Must be at least %s", limitStr)
+			}
+		case "max":
+			if isNumber && num > limit {
+				return option, fmt.Errorf("Must be at most %s", limitStr)
+			}
+		case "minlen":
+			if length >= 0 && float64(length) < limit {
+				return option, fmt.Errorf("Must have at least %s %s", limitStr, unit)
+			}
+		case "maxlen":
+			if length >= 0 && float64(length) > limit {
+				return option, fmt.Errorf("Must have at most %s %s", limitStr, unit)
+			}
+		}
+	}
+	return "", nil
+}
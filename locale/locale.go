@@ -0,0 +1,107 @@
+// Package locale reads the language and time zone a client asked for
+// from its request headers, and matches the language against the
+// locales an application supports:
+//
+//	locale.SetSupported(language.English, language.French, language.German)
+//
+//	loc := locale.ParseLocale(request)
+//	fmt.Println(loc.Matched, loc.Location)
+//
+// The Locale can be attached to a context.Context with WithLocale, so
+// that a web_request_readers.MessageProvider can render FieldErrors
+// in the client's language.
+package locale
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// TimezoneHeader is the request header that time zones are read from,
+// as IANA names, e.g. "Europe/Paris".  If it is empty, time zones
+// aren't read.
+var TimezoneHeader = "X-Timezone"
+
+var (
+	supported []language.Tag
+	matcher   language.Matcher
+)
+
+// SetSupported sets the locales that ParseLocale matches requests
+// against, most preferred first; the first is used when nothing
+// matches.  Like web_request_readers.RegisterConverter, it should be
+// called before any requests are handled.
+func SetSupported(tags ...language.Tag) {
+	supported = append([]language.Tag(nil), tags...)
+	matcher = nil
+	if len(supported) > 0 {
+		matcher = language.NewMatcher(supported)
+	}
+}
+
+// A Locale is the language and time zone a client asked for.
+type Locale struct {
+	// Tags are the languages from the Accept-Language header, most
+	// preferred first.
+	Tags []language.Tag
+
+	// Matched is the supported locale that best matches Tags (see
+	// SetSupported), and Confidence is how well it matches.  If no
+	// locales are supported, Matched is the first of Tags (or
+	// language.Und), with language.Exact confidence.
+	Matched    language.Tag
+	Confidence language.Confidence
+
+	// Location is the time zone from TimezoneHeader, or nil if the
+	// client didn't send a valid one.
+	Location *time.Location
+}
+
+// ParseLocale reads the Accept-Language and TimezoneHeader headers of
+// r.  Malformed headers are treated as absent, so ParseLocale always
+// returns a usable Locale.
+func ParseLocale(r *http.Request) Locale {
+	var loc Locale
+	for _, header := range r.Header.Values("Accept-Language") {
+		tags, _, err := language.ParseAcceptLanguage(header)
+		if err == nil {
+			loc.Tags = append(loc.Tags, tags...)
+		}
+	}
+	if matcher != nil {
+		_, index, confidence := matcher.Match(loc.Tags...)
+		loc.Matched, loc.Confidence = supported[index], confidence
+	} else {
+		loc.Matched, loc.Confidence = language.Und, language.Exact
+		if len(loc.Tags) > 0 {
+			loc.Matched = loc.Tags[0]
+		}
+	}
+	if TimezoneHeader != "" {
+		// "Local" would be the server's time zone, not the
+		// client's.
+		if name := r.Header.Get(TimezoneHeader); name != "" && name != "Local" {
+			if location, err := time.LoadLocation(name); err == nil {
+				loc.Location = location
+			}
+		}
+	}
+	return loc
+}
+
+// localeKey is the context.Context key for a Locale.
+type localeKey struct{}
+
+// WithLocale returns a copy of ctx carrying loc.
+func WithLocale(ctx context.Context, loc Locale) context.Context {
+	return context.WithValue(ctx, localeKey{}, loc)
+}
+
+// FromContext returns the Locale attached to ctx with WithLocale.
+func FromContext(ctx context.Context) (Locale, bool) {
+	loc, ok := ctx.Value(localeKey{}).(Locale)
+	return loc, ok
+}
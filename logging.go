@@ -0,0 +1,36 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+)
+
+// logDebug logs msg at Debug level to the logger for the current
+// unmarshal, if there is one.
+func (state *unmarshalState) logDebug(msg string, attrs ...slog.Attr) {
+	if state.logger == nil {
+		return
+	}
+	state.logger.LogAttrs(state.requestContext(), slog.LevelDebug, msg, attrs...)
+}
+
+// errorAttr returns a slog attribute for err, which may be nil.
+func errorAttr(err error) slog.Attr {
+	if err == nil {
+		return slog.Attr{}
+	}
+	return slog.String("error", err.Error())
+}
+
+// valueAttr returns a slog attribute describing a request value by its
+// type and length, rather than the value itself, which may be a
+// password or some other secret that mustn't reach the logs.
+func valueAttr(value interface{}) slog.Attr {
+	attrs := []slog.Attr{slog.String("type", fmt.Sprintf("%T", value))}
+	switch reflected := reflect.ValueOf(value); reflected.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		attrs = append(attrs, slog.Int("len", reflected.Len()))
+	}
+	return slog.Attr{Key: "value", Value: slog.GroupValue(attrs...)}
+}
@@ -0,0 +1,31 @@
+package web_request_readers
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/objx"
+)
+
+type testLogin struct {
+	Pin int `request:"pin"`
+}
+
+func TestRejectedValuesAreNotLogged(t *testing.T) {
+	var logs bytes.Buffer
+	unmarshaler := &Unmarshaler{Logger: slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+	if err := unmarshaler.UnmarshalParams(objx.Map{"pin": []interface{}{"hunter2"}}, &testLogin{}); err == nil {
+		t.Fatal("expected an error for a non-numeric pin")
+	}
+	if !strings.Contains(logs.String(), "rejected request value") {
+		t.Fatalf("rejection wasn't logged: %s", logs.String())
+	}
+	if strings.Contains(logs.String(), "hunter2") {
+		t.Errorf("rejected value was logged: %s", logs.String())
+	}
+	if !strings.Contains(logs.String(), `value.type="[]interface {}" value.len=1`) {
+		t.Errorf("rejected value wasn't described: %s", logs.String())
+	}
+}
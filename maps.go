@@ -0,0 +1,50 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// setMap unmarshals a request object to a map field, converting each
+// key and element to the map's key and element types.  Struct
+// elements are unmarshalled like nested structs whose key is the map
+// key, e.g. "labels.en", so errors and missing fields name the
+// element they came from.
+func (state *unmarshalState) setMap(target reflect.Value, value interface{}) error {
+	src := reflect.ValueOf(value)
+	if src.Kind() != reflect.Map || src.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("Cannot convert value of type %T to a map", value)
+	}
+	name, goName := state.fieldName, state.goFieldName
+	defer func() {
+		state.fieldName, state.goFieldName = name, goName
+	}()
+
+	keys := make([]string, 0, src.Len())
+	for _, key := range src.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+
+	mapType := target.Type()
+	result := reflect.MakeMapWithSize(mapType, len(keys))
+	for _, key := range keys {
+		keyValue := reflect.New(mapType.Key()).Elem()
+		if err := state.setValue(keyValue, key); err != nil {
+			return fmt.Errorf("Cannot convert map key %q: %s", key, err)
+		}
+		state.fieldName = name + "." + key
+		state.goFieldName = fmt.Sprintf("%s[%q]", goName, key)
+		elemValue := reflect.New(mapType.Elem()).Elem()
+		if err := state.setValue(elemValue, src.MapIndex(reflect.ValueOf(key).Convert(src.Type().Key())).Interface()); err != nil {
+			if _, ok := err.(nestedError); ok {
+				return err
+			}
+			return fmt.Errorf("Cannot convert value for map key %q: %s", key, err)
+		}
+		result.SetMapIndex(keyValue, elemValue)
+	}
+	target.Set(result)
+	return nil
+}
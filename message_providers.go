@@ -0,0 +1,64 @@
+package web_request_readers
+
+import (
+	gocontext "context"
+)
+
+// A MessageProvider renders the messages of FieldErrors, so that
+// validation and conversion errors can be returned in the language
+// of the request.  It is set on Unmarshaler.Messages.
+//
+// Message is called with the context of the request (see
+// Unmarshaler.Messages for which context that is), the error code
+// (e.g. "invalid", "min", or "pattern"), the request key of the field,
+// and params describing the error:
+//
+//	"value"  the request value
+//	"error"  the default (English) error message
+//	<code>   the value of the tag option named after the code, if
+//	         the field has one, e.g. "5" for code "min" and a
+//	         min=5 option
+//
+// If Message returns "", the default message is used.
+type MessageProvider interface {
+	Message(ctx gocontext.Context, code, field string, params map[string]interface{}) string
+}
+
+// MessageProviderFunc is a function that implements MessageProvider.
+type MessageProviderFunc func(ctx gocontext.Context, code, field string, params map[string]interface{}) string
+
+// Message calls f.
+func (f MessageProviderFunc) Message(ctx gocontext.Context, code, field string, params map[string]interface{}) string {
+	return f(ctx, code, field, params)
+}
+
+// providedMessage asks the unmarshaler's MessageProvider for the
+// message for an error, returning "" if there is no provider or it has
+// no message.
+func (state *unmarshalState) providedMessage(field string, args []string, code string, value interface{}, err error) string {
+	provider := state.unmarshaler.Messages
+	if provider == nil {
+		return ""
+	}
+	params := map[string]interface{}{
+		"value": value,
+		"error": err.Error(),
+	}
+	if option, ok := tagOption(args, code); ok {
+		params[code] = option
+	}
+	return provider.Message(state.requestContext(), code, field, params)
+}
+
+// requestContext returns the context of the unmarshal, for
+// rendering messages and tracing: the context passed to
+// UnmarshalParamsCtx, or else the context of the request that params
+// came from, or else context.Background().
+func (state *unmarshalState) requestContext() gocontext.Context {
+	if state.goCtx == nil && state.ctx != nil {
+		if request := state.ctx.HttpRequest(); request != nil {
+			return request.Context()
+		}
+	}
+	return state.goContext()
+}
@@ -8,10 +8,36 @@ import (
 // do not have values from a request.  This doesn't always matter
 // (e.g. during a PATCH request), but can be a problem if a request
 // was supposed to include values for all fields in a model.
+//
+// A MissingFields error marshals to JSON as an ErrorResponse, so it
+// can be written directly as the body of a 422 response.
 type MissingFields struct {
 	// Names stores the names that were expected to be in a request,
 	// but were not found.
 	Names []string
+
+	// Fields describes each of the missing fields in more detail,
+	// in the same order as Names.  It may be shorter than Names if
+	// the error was built by hand; use Entries to read it.
+	Fields []MissingField
+}
+
+// A MissingField describes a single field that was missing from a
+// request.
+type MissingField struct {
+	// RequestKey is the dotted path of request keys that was
+	// expected, e.g. "address.street".
+	RequestKey string `json:"key"`
+
+	// FieldPath is the dotted path of struct field names leading to
+	// the field, e.g. "Address.Street".  It tells apart fields of
+	// embedded structs, which share their parent's keys.
+	FieldPath string `json:"field,omitempty"`
+
+	// Required is true if the field is always required, and false
+	// if it was only required by a required_if or required_with
+	// option.
+	Required bool `json:"required"`
 }
 
 // Error returns the error message for a MissingFields error.
@@ -22,7 +48,14 @@ func (err MissingFields) Error() string {
 // AddMissingField adds a name that was missing from a request to the
 // MissingFields error's list of missing fields.
 func (err *MissingFields) AddMissingField(fieldName string) {
-	err.Names = append(err.Names, fieldName)
+	err.AddMissing(MissingField{RequestKey: fieldName, Required: true})
+}
+
+// AddMissing adds a field that was missing from a request to the
+// MissingFields error's list of missing fields.
+func (err *MissingFields) AddMissing(field MissingField) {
+	err.Names = append(err.Names, field.RequestKey)
+	err.Fields = append(err.Fields, field)
 }
 
 // HasMissingFields returns whether or not there are any fields that
@@ -30,3 +63,17 @@ func (err *MissingFields) AddMissingField(fieldName string) {
 func (err MissingFields) HasMissingFields() bool {
 	return len(err.Names) > 0
 }
+
+// Entries returns a MissingField for each name in err.Names, filling
+// in any that aren't described in err.Fields from the name alone.
+func (err MissingFields) Entries() []MissingField {
+	entries := make([]MissingField, len(err.Names))
+	for index, name := range err.Names {
+		if index < len(err.Fields) && err.Fields[index].RequestKey == name {
+			entries[index] = err.Fields[index]
+		} else {
+			entries[index] = MissingField{RequestKey: name, Required: true}
+		}
+	}
+	return entries
+}
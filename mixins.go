@@ -0,0 +1,127 @@
+package web_request_readers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stretchr/objx"
+)
+
+// The types in this file are meant to be embedded in list-endpoint
+// request models, so that common parameters are declared once:
+//
+//	type ListUsersRequest struct {
+//	    web_request_readers.PageRequest
+//	    web_request_readers.SortRequest
+//	    web_request_readers.DateRangeRequest
+//	    Status string `request:"status,optional"`
+//	}
+
+// PageRequest reads the "page" and "page_size" parameters.  See
+// ParsePagination.
+type PageRequest struct {
+	Page     *int `request:"page,optional"`
+	PageSize *int `request:"page_size,optional"`
+}
+
+// Pagination returns the Page that the request asked for, using the
+// same defaults and validation as ParsePagination.
+func (request PageRequest) Pagination(defaultPageSize int) (*Page, error) {
+	params := make(objx.Map, 2)
+	if request.Page != nil {
+		params["page"] = *request.Page
+	}
+	if request.PageSize != nil {
+		params["page_size"] = *request.PageSize
+	}
+	return ParsePagination(params, defaultPageSize)
+}
+
+// SortRequest reads the "sort" parameter.  See ParseSort.
+type SortRequest struct {
+	Sort SortValue `request:"sort,optional"`
+}
+
+// SortFields returns the parsed sort order, checking every field
+// against allowed.
+func (request SortRequest) SortFields(allowed ...string) ([]SortField, error) {
+	return parseSortValue(string(request.Sort), allowed)
+}
+
+// SortValue is a comma-separated sort order that can be received from
+// a single request value (sort=-created,name) or from repeated ones
+// (sort=-created&sort=name), the same as ParseSort accepts.
+type SortValue string
+
+// Receive reads a sort order from a request value, joining repeated
+// values with commas.
+func (sort *SortValue) Receive(value interface{}) error {
+	switch src := value.(type) {
+	case string:
+		*sort = SortValue(src)
+	case []string:
+		*sort = SortValue(strings.Join(src, ","))
+	case []interface{}:
+		names := make([]string, len(src))
+		for index, elem := range src {
+			name, ok := elem.(string)
+			if !ok {
+				return fmt.Errorf("Cannot read %s from a list containing a value of type %T", SortParam, elem)
+			}
+			names[index] = name
+		}
+		*sort = SortValue(strings.Join(names, ","))
+	default:
+		return fmt.Errorf("Cannot read %s from value of type %T", SortParam, value)
+	}
+	return nil
+}
+
+// DateRangeRequest reads the "from" and "to" parameters as times.
+type DateRangeRequest struct {
+	From *RequestTime `request:"from,optional"`
+	To   *RequestTime `request:"to,optional"`
+}
+
+// Range returns the requested range.  Missing ends of the range are
+// returned as zero times.  An error is returned if the range ends
+// before it starts.
+func (request DateRangeRequest) Range() (from, to time.Time, err error) {
+	if request.From != nil {
+		from = request.From.Time
+	}
+	if request.To != nil {
+		to = request.To.Time
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return from, to, errors.New("Date range cannot end before it starts")
+	}
+	return from, to, nil
+}
+
+// RequestTimeFormats are the formats that RequestTime will try, in
+// order, when parsing a time from a request.
+var RequestTimeFormats = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"}
+
+// RequestTime is a time.Time that can be received from a request
+// string in any of the RequestTimeFormats.
+type RequestTime struct {
+	time.Time
+}
+
+// Receive parses a time from a request value.
+func (requestTime *RequestTime) Receive(value interface{}) error {
+	str, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("Cannot read time from value of type %T", value)
+	}
+	for _, format := range RequestTimeFormats {
+		if parsed, err := time.Parse(format, str); err == nil {
+			requestTime.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("Cannot parse %q as a time", str)
+}
@@ -0,0 +1,82 @@
+package web_request_readers
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/objx"
+)
+
+type testListUsers struct {
+	PageRequest
+	SortRequest
+	DateRangeRequest
+	Status string `request:"status,optional"`
+}
+
+func TestListMixins(t *testing.T) {
+	u, _ := url.Parse("/?page=2&page_size=10&sort=-created&sort=name&from=2024-01-01&to=2024-02-01T10:00:00&status=active")
+	var request testListUsers
+	if err := UnmarshalParams(ParseQuery(u), &request); err != nil {
+		t.Fatal(err)
+	}
+	page, err := request.Pagination(20)
+	if err != nil || page.Offset != 10 || page.Limit != 10 {
+		t.Errorf("unexpected page %+v, %v", page, err)
+	}
+	fields, err := request.SortFields("created", "name")
+	want := []SortField{{Field: "created", Descending: true}, {Field: "name"}}
+	if err != nil || !reflect.DeepEqual(fields, want) {
+		t.Errorf("got sort %v, %v; want %v", fields, err, want)
+	}
+	from, to, err := request.Range()
+	if err != nil || !from.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected range %v to %v, %v", from, to, err)
+	}
+	if request.Status != "active" {
+		t.Errorf("unexpected status %q", request.Status)
+	}
+}
+
+func TestSortRequestForms(t *testing.T) {
+	want := []SortField{{Field: "created", Descending: true}, {Field: "name"}}
+	for _, value := range []interface{}{
+		"-created,name",
+		[]string{"-created", "name"},
+		[]interface{}{"-created", "name"},
+	} {
+		var request SortRequest
+		if err := UnmarshalParams(objx.Map{"sort": value}, &request); err != nil {
+			t.Errorf("%#v: %v", value, err)
+			continue
+		}
+		fields, err := request.SortFields("created", "name")
+		if err != nil || !reflect.DeepEqual(fields, want) {
+			t.Errorf("%#v: got %v, %v", value, fields, err)
+		}
+	}
+
+	var request SortRequest
+	if err := UnmarshalParams(objx.Map{"sort": []interface{}{"name", 1.0}}, &request); err == nil {
+		t.Error("accepted a sort list with a number in it")
+	}
+	request = SortRequest{Sort: "secret"}
+	if _, err := request.SortFields("name"); err == nil {
+		t.Error("accepted a field that isn't allowed")
+	}
+}
+
+func TestDateRangeRequestRejectsBackwardsRanges(t *testing.T) {
+	var request DateRangeRequest
+	if err := UnmarshalParams(objx.Map{"from": "2024-02-01", "to": "2024-01-01"}, &request); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := request.Range(); err == nil {
+		t.Error("accepted a range that ends before it starts")
+	}
+	if err := UnmarshalParams(objx.Map{"from": "yesterday"}, &request); err == nil {
+		t.Error("accepted an unparseable time")
+	}
+}
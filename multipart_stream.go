@@ -0,0 +1,129 @@
+package web_request_readers
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/stretchr/goweb/context"
+	"github.com/stretchr/objx"
+)
+
+// A PartHandler receives the file parts of a streamed multipart
+// request.  The part provides the field name, file name, and headers;
+// the file content should be read from content rather than from the
+// part itself, since some of it may already have been buffered for
+// file type detection.  Returning an error stops the stream.
+type PartHandler func(part *multipart.Part, content io.Reader) error
+
+// ErrValueTooLarge is returned by ParseMultipartStream when the
+// non-file fields of a request are larger than MultipartMem.
+var ErrValueTooLarge = errors.New("Multipart form values are too large")
+
+// ParseMultipartStream reads a multipart/form-data request body one
+// part at a time, rather than buffering the whole thing the way
+// ParseBody does.  File parts (parts with a file name) are passed to
+// handler as they arrive, so multi-gigabyte uploads can be written
+// straight to their destination.  All other parts are collected into
+// the returned params, using the same single-value flattening that
+// ParseBody uses.
+//
+// File parts are still checked against SetAllowedFileTypes before the
+// handler sees them.  Non-file values are limited to MultipartMem
+// bytes in total.
+//
+// As with ParseBody, the params include the query string's values,
+// and are cached in ctx.Data(), so later calls to ParseParams will
+// return the non-file values.  If Options.HoneypotFields are set and
+// any of them has a value, the error is a SpamDetected; since the
+// body is streamed, the handler may already have seen earlier file
+// parts by then.
+func ParseMultipartStream(ctx context.Context, handler PartHandler) (objx.Map, error) {
+	request := ctx.HttpRequest()
+	reader, err := request.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string][]string)
+	remaining := MultipartMem()
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := part.FormName()
+		if name == "" {
+			part.Close()
+			continue
+		}
+
+		if part.FileName() == "" {
+			var buf bytes.Buffer
+			n, err := io.CopyN(&buf, part, remaining+1)
+			part.Close()
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			remaining -= n
+			if remaining < 0 {
+				return nil, ErrValueTooLarge
+			}
+			values[name] = append(values[name], buf.String())
+			continue
+		}
+
+		err = streamFilePart(name, part, handler)
+		part.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for key, queryValues := range request.URL.Query() {
+		values[key] = append(values[key], queryValues...)
+	}
+	params := make(objx.Map)
+	setFormValues(params, values)
+	CacheParams(ctx, params)
+	if opts, _ := RequestOptions(ctx); len(opts.HoneypotFields) > 0 {
+		if err := checkHoneypots(params, opts.HoneypotFields); err != nil {
+			return nil, err
+		}
+	}
+	return params, nil
+}
+
+// streamFilePart checks a file part's type (when the field has
+// allowed file types) and hands it off to handler.
+func streamFilePart(name string, part *multipart.Part, handler PartHandler) error {
+	allowed := AllowedFileTypes(name)
+	if allowed == nil {
+		return handler(part, part)
+	}
+	content := bufio.NewReaderSize(part, sniffLen)
+	head, err := content.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return err
+	}
+	detected := http.DetectContentType(head)
+	if mimeType, _, err := mime.ParseMediaType(detected); err == nil {
+		detected = mimeType
+	}
+	if !mimeTypeAllowed(detected, allowed) {
+		return FileTypeError{
+			Field:        name,
+			Filename:     part.FileName(),
+			DetectedType: detected,
+			Allowed:      allowed,
+		}
+	}
+	return handler(part, content)
+}
@@ -0,0 +1,128 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	codec_services "github.com/stretchr/codecs/services"
+)
+
+// NotAcceptable is the error returned by NegotiateResponseType when
+// none of the offered types is acceptable to the client.
+type NotAcceptable struct {
+	// Accept is the request's Accept header.
+	Accept string
+
+	// Offered are the types the handler could have responded with.
+	Offered []string
+}
+
+// Error returns the error message for a NotAcceptable error.
+func (err NotAcceptable) Error() string {
+	return fmt.Sprintf("None of the available content types (%s) is acceptable for %q",
+		strings.Join(err.Offered, ", "), err.Accept)
+}
+
+// mediaRange is one entry of an Accept header.
+type mediaRange struct {
+	mimeType string
+	params   map[string]string
+	quality  float64
+}
+
+// NegotiateResponseType chooses which of the offered content types to
+// respond to r with, according to its Accept header.  Each offered
+// type gets the quality of the most specific media range that matches
+// it ("text/html;level=1" over "text/html" over "text/*" over "*/*"),
+// and the type with the highest quality wins; ties go to the type
+// that was offered first, so handlers list their preferred type
+// first.  Types with a quality of 0, or that no range matches, are
+// never chosen.
+//
+// Requests without an Accept header accept anything, so they get the
+// first offered type.  Malformed ranges are skipped.  If nothing is
+// acceptable, a NotAcceptable error is returned (which SuggestedStatus
+// reports as 406).
+func NegotiateResponseType(r *http.Request, offered []string) (string, error) {
+	accept := strings.Join(r.Header.Values("Accept"), ",")
+	if strings.TrimSpace(accept) == "" {
+		if len(offered) == 0 {
+			return "", NotAcceptable{Offered: offered}
+		}
+		return offered[0], nil
+	}
+	ranges := parseAccept(accept)
+	best, bestQuality := "", 0.0
+	for _, candidate := range offered {
+		contentType, err := codec_services.ParseContentType(candidate)
+		if err != nil {
+			continue
+		}
+		if quality := acceptQuality(ranges, contentType); quality > bestQuality {
+			best, bestQuality = candidate, quality
+		}
+	}
+	if best == "" {
+		return "", NotAcceptable{Accept: accept, Offered: offered}
+	}
+	return best, nil
+}
+
+// parseAccept parses the media ranges of an Accept header, skipping
+// any that are malformed.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, entry := range strings.Split(accept, ",") {
+		contentType, err := codec_services.ParseContentType(strings.TrimSpace(entry))
+		if err != nil || !strings.Contains(contentType.MimeType, "/") {
+			continue
+		}
+		parsed := mediaRange{mimeType: contentType.MimeType, params: contentType.Parameters, quality: 1}
+		if q, ok := parsed.params["q"]; ok {
+			quality, err := strconv.ParseFloat(q, 64)
+			if err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+			parsed.quality = quality
+			delete(parsed.params, "q")
+		}
+		ranges = append(ranges, parsed)
+	}
+	return ranges
+}
+
+// acceptQuality returns the quality of the most specific range that
+// matches contentType, or 0 if none does.
+func acceptQuality(ranges []mediaRange, contentType *codec_services.ContentType) float64 {
+	quality, specificity := 0.0, -1
+	for _, candidate := range ranges {
+		score, ok := candidate.match(contentType)
+		if ok && score > specificity {
+			quality, specificity = candidate.quality, score
+		}
+	}
+	return quality
+}
+
+// match returns whether the range matches contentType, and how
+// specific the match is.
+func (candidate mediaRange) match(contentType *codec_services.ContentType) (int, bool) {
+	for key, value := range candidate.params {
+		if !strings.EqualFold(contentType.Parameters[key], value) {
+			return 0, false
+		}
+	}
+	rangeType, rangeSubtype, _ := strings.Cut(candidate.mimeType, "/")
+	offeredType, offeredSubtype, _ := strings.Cut(contentType.MimeType, "/")
+	switch {
+	case rangeType == "*" && rangeSubtype == "*":
+		return len(candidate.params), true
+	case rangeType == offeredType && rangeSubtype == "*":
+		return 100 + len(candidate.params), true
+	case rangeType == offeredType && rangeSubtype == offeredSubtype:
+		return 200 + len(candidate.params), true
+	}
+	return 0, false
+}
@@ -0,0 +1,147 @@
+package web_request_readers
+
+import (
+	"log/slog"
+	"reflect"
+	"strings"
+
+	"github.com/stretchr/objx"
+)
+
+// nestedError wraps errors from nested structs, which already name
+// their own fields and shouldn't be wrapped again by the parent.
+type nestedError struct {
+	err error
+}
+
+func (err nestedError) Error() string {
+	return err.err.Error()
+}
+
+// asParams returns value as a plain map, if it is a map with string
+// keys: either a map[string]interface{} or an objx.Map.
+func asParams(value interface{}) (map[string]interface{}, bool) {
+	switch src := value.(type) {
+	case map[string]interface{}:
+		return src, true
+	case objx.Map:
+		return src, true
+	}
+	return nil, false
+}
+
+// setParamPath sets value at a dotted path in params, e.g.
+// "address.city", making plain maps for any objects on the path that
+// are missing.
+func setParamPath(params map[string]interface{}, path string, value interface{}) {
+	for {
+		key, rest, nested := strings.Cut(path, ".")
+		if !nested {
+			params[key] = value
+			return
+		}
+		child, ok := asParams(params[key])
+		if !ok {
+			child = make(map[string]interface{})
+			params[key] = child
+		}
+		params, path = child, rest
+	}
+}
+
+// child returns the state for unmarshalling a nested struct from
+// params.  Options, the result, and missing fields are shared with
+// the parent; keys are tracked relative to the nested params.
+func (state *unmarshalState) child(params map[string]interface{}) *unmarshalState {
+	child := state.unmarshaler.newState(state.ctx, params)
+	child.goCtx = state.goCtx
+	child.patch = state.patch
+	child.coercions = state.coercions
+	child.strict = state.strict
+	child.tagName = state.tagName
+	child.defaultRequired = state.defaultRequired
+	child.duplicateKeys = state.duplicateKeys
+	child.logger = state.logger
+	child.result = state.result
+	child.recordChanges = state.recordChanges
+	child.missing = state.missing
+	child.forbidden = state.forbidden
+	child.allowed = state.allowed
+	child.scopes = state.scopes
+	child.keyPath = state.keyPath + state.fieldName + "."
+	child.fieldPath = state.fieldPath + state.goFieldName + "."
+	child.ancestors = append(state.ancestors[:len(state.ancestors):len(state.ancestors)], reflect.ValueOf(state.params).Pointer())
+	return child
+}
+
+// setStruct unmarshals a request object to a nested struct field,
+// running the same lifecycle as a top-level target: PreUnmarshal,
+// then either Unmarshal (for an Unmarshaller) or field-by-field
+// unmarshalling followed by ComputeFields and ValidateFields, then
+// PostUnmarshal if nothing failed.
+func (state *unmarshalState) setStruct(target reflect.Value, params map[string]interface{}) (err error) {
+	if err := state.checkNesting(params); err != nil {
+		return err
+	}
+	var targetPtr interface{}
+	if target.CanAddr() {
+		targetPtr = target.Addr().Interface()
+	}
+
+	if preUnmarshaller, ok := targetPtr.(PreUnmarshaller); ok {
+		if err := preUnmarshaller.PreUnmarshal(); err != nil {
+			return nestedError{err}
+		}
+	}
+	if postUnmarshaller, ok := targetPtr.(PostUnmarshaller); ok {
+		defer func() {
+			if err == nil {
+				if postErr := postUnmarshaller.PostUnmarshal(); postErr != nil {
+					err = nestedError{postErr}
+				}
+			}
+		}()
+	}
+	if unmarshaller, ok := targetPtr.(Unmarshaller); ok {
+		if err := unmarshaller.Unmarshal(params); err != nil {
+			return nestedError{err}
+		}
+		return nil
+	}
+
+	child := state.child(params)
+	defer child.release()
+	if messages, ok := targetPtr.(FieldMessages); ok {
+		child.messages = messages.Messages()
+	}
+	validator, hasValidate := targetPtr.(CrossValidator)
+	if hasValidate {
+		child.changed = make(map[string]interface{})
+	}
+	matchedFields, err := child.unmarshalToValue(target)
+	if err != nil {
+		return nestedError{err}
+	}
+	if computer, ok := targetPtr.(FieldComputer); ok {
+		if err := computer.ComputeFields(); err != nil {
+			return nestedError{err}
+		}
+	}
+	if hasValidate {
+		if err := validator.ValidateFields(child.changed); err != nil {
+			return nestedError{err}
+		}
+	}
+	if key := state.discriminator; key != "" && !child.consumed[key] {
+		if _, ok := params[key]; ok {
+			child.consumed[key] = true
+			matchedFields++
+		}
+	}
+	if matchedFields < len(params) {
+		extra := child.extraFields(state.fieldName)
+		child.logDebug("unknown request keys", slog.Any("keys", extra.Names))
+		return nestedError{extra}
+	}
+	return nil
+}
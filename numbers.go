@@ -0,0 +1,217 @@
+package web_request_readers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// RangeError is the error returned when a request number can't be
+// stored in its field without changing it: it is too large or too
+// small for the field's kind, or it has a fractional part and the
+// field holds integers.
+type RangeError struct {
+	// Value is the value that was in the request.
+	Value interface{}
+
+	// Kind is the kind of the field, e.g. reflect.Int8.
+	Kind reflect.Kind
+
+	// Fractional is true when Value was in range, but isn't a
+	// whole number.
+	Fractional bool
+}
+
+// Error returns the error message for a RangeError error.
+func (err RangeError) Error() string {
+	if err.Fractional {
+		return fmt.Sprintf("Value %v is not a whole number", err.Value)
+	}
+	return fmt.Sprintf("Value %v is out of range for %s", err.Value, err.Kind)
+}
+
+// truncateNumbers returns whether fractional numbers may be truncated
+// for the field currently being set.
+func (state *unmarshalState) truncateNumbers() bool {
+	return state.truncate || (state.unmarshaler.TruncateFloats && !state.strict)
+}
+
+// setInt sets a signed integer field, checking that value fits in it.
+// Fractional numbers are truncated if truncate is true.
+func setInt(target reflect.Value, value interface{}, truncate bool) error {
+	var intVal int64
+	switch src := value.(type) {
+	case string:
+		parsed, err := strconv.ParseInt(src, 10, 64)
+		if err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				return RangeError{Value: value, Kind: target.Kind()}
+			}
+			return err
+		}
+		intVal = parsed
+	case json.Number:
+		parsed, err := strconv.ParseInt(string(src), 10, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			return RangeError{Value: value, Kind: target.Kind()}
+		}
+		if err != nil {
+			floatVal, floatErr := src.Float64()
+			if floatErr != nil {
+				return err
+			}
+			return setIntFromFloat(target, value, floatVal, truncate)
+		}
+		intVal = parsed
+	case int, int8, int16, int32, int64:
+		intVal = reflect.ValueOf(src).Int()
+	case uint, uint8, uint16, uint32, uint64:
+		uintVal := reflect.ValueOf(src).Uint()
+		if uintVal > math.MaxInt64 {
+			return RangeError{Value: value, Kind: target.Kind()}
+		}
+		intVal = int64(uintVal)
+	case float32:
+		return setIntFromFloat(target, value, float64(src), truncate)
+	case float64:
+		return setIntFromFloat(target, value, src, truncate)
+	default:
+		return nil
+	}
+	if target.OverflowInt(intVal) {
+		return RangeError{Value: value, Kind: target.Kind()}
+	}
+	target.SetInt(intVal)
+	return nil
+}
+
+// setIntFromFloat sets a signed integer field from a floating-point
+// number.
+func setIntFromFloat(target reflect.Value, value interface{}, floatVal float64, truncate bool) error {
+	if err := checkWhole(target, value, floatVal, truncate); err != nil {
+		return err
+	}
+	// -2^63 is exactly representable, but 2^63-1 isn't, so the
+	// upper bound is exclusive.
+	if floatVal < math.MinInt64 || floatVal >= -math.MinInt64 || target.OverflowInt(int64(floatVal)) {
+		return RangeError{Value: value, Kind: target.Kind()}
+	}
+	target.SetInt(int64(floatVal))
+	return nil
+}
+
+// setUint sets an unsigned integer field, checking that value fits
+// in it.  Fractional numbers are truncated if truncate is true.
+func setUint(target reflect.Value, value interface{}, truncate bool) error {
+	var uintVal uint64
+	switch src := value.(type) {
+	case string:
+		parsed, err := strconv.ParseUint(src, 10, 64)
+		if err != nil {
+			if _, intErr := strconv.ParseInt(src, 10, 64); errors.Is(err, strconv.ErrRange) || intErr == nil {
+				// Too large, or negative.
+				return RangeError{Value: value, Kind: target.Kind()}
+			}
+			return err
+		}
+		uintVal = parsed
+	case json.Number:
+		parsed, err := strconv.ParseUint(string(src), 10, 64)
+		if err != nil {
+			floatVal, floatErr := src.Float64()
+			if floatErr != nil {
+				return err
+			}
+			return setUintFromFloat(target, value, floatVal, truncate)
+		}
+		uintVal = parsed
+	case int, int8, int16, int32, int64:
+		intVal := reflect.ValueOf(src).Int()
+		if intVal < 0 {
+			return RangeError{Value: value, Kind: target.Kind()}
+		}
+		uintVal = uint64(intVal)
+	case uint, uint8, uint16, uint32, uint64:
+		uintVal = reflect.ValueOf(src).Uint()
+	case float32:
+		return setUintFromFloat(target, value, float64(src), truncate)
+	case float64:
+		return setUintFromFloat(target, value, src, truncate)
+	default:
+		return nil
+	}
+	if target.OverflowUint(uintVal) {
+		return RangeError{Value: value, Kind: target.Kind()}
+	}
+	target.SetUint(uintVal)
+	return nil
+}
+
+// setUintFromFloat sets an unsigned integer field from a
+// floating-point number.
+func setUintFromFloat(target reflect.Value, value interface{}, floatVal float64, truncate bool) error {
+	if err := checkWhole(target, value, floatVal, truncate); err != nil {
+		return err
+	}
+	if floatVal <= -1 || floatVal >= math.MaxUint64 || target.OverflowUint(uint64(floatVal)) {
+		return RangeError{Value: value, Kind: target.Kind()}
+	}
+	target.SetUint(uint64(floatVal))
+	return nil
+}
+
+// checkWhole returns a RangeError if floatVal isn't a finite whole
+// number, unless truncate allows a fractional part.
+func checkWhole(target reflect.Value, value interface{}, floatVal float64, truncate bool) error {
+	if math.IsNaN(floatVal) || math.IsInf(floatVal, 0) {
+		return RangeError{Value: value, Kind: target.Kind()}
+	}
+	if !truncate && floatVal != math.Trunc(floatVal) {
+		return RangeError{Value: value, Kind: target.Kind(), Fractional: true}
+	}
+	return nil
+}
+
+// setFloat sets a floating-point field, checking that value fits in
+// it.
+func setFloat(target reflect.Value, value interface{}) error {
+	var floatVal float64
+	switch src := value.(type) {
+	case string:
+		parsed, err := strconv.ParseFloat(src, 64)
+		if err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				return RangeError{Value: value, Kind: target.Kind()}
+			}
+			return err
+		}
+		floatVal = parsed
+	case json.Number:
+		parsed, err := strconv.ParseFloat(string(src), 64)
+		if err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				return RangeError{Value: value, Kind: target.Kind()}
+			}
+			return err
+		}
+		floatVal = parsed
+	case int, int8, int16, int32, int64:
+		floatVal = float64(reflect.ValueOf(src).Int())
+	case uint, uint8, uint16, uint32, uint64:
+		floatVal = float64(reflect.ValueOf(src).Uint())
+	case float32:
+		floatVal = float64(src)
+	case float64:
+		floatVal = src
+	default:
+		return nil
+	}
+	if target.OverflowFloat(floatVal) {
+		return RangeError{Value: value, Kind: target.Kind()}
+	}
+	target.SetFloat(floatVal)
+	return nil
+}
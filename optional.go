@@ -0,0 +1,75 @@
+package web_request_readers
+
+import (
+	"reflect"
+)
+
+// Optional wraps a field's value with flags that record whether its
+// key was present in the request, and whether it was explicitly null.
+// This gives models all three states that PATCH requests care about,
+// where sql.Null* types (and pointers) can only represent two:
+//
+//	type UserPatch struct {
+//	    Nickname Optional[string]
+//	}
+//
+//	// {}                  -> Present: false
+//	// {"nickname": null}  -> Present: true, Null: true
+//	// {"nickname": "bob"} -> Present: true, Value: "bob"
+//
+// Optional fields are never reported in MissingFields, since being
+// absent is one of the states they represent.
+type Optional[T any] struct {
+	Value   T
+	Present bool
+	Null    bool
+}
+
+// Get returns the value and whether or not a non-null value was
+// present in the request.
+func (optional Optional[T]) Get() (T, bool) {
+	return optional.Value, optional.Present && !optional.Null
+}
+
+// optionalValue is implemented by *Optional[T] so that setValue can
+// populate it without knowing T.
+type optionalValue interface {
+	receiveOptional(state *unmarshalState, value interface{}) error
+}
+
+func (optional *Optional[T]) receiveOptional(state *unmarshalState, value interface{}) error {
+	optional.Present = true
+	optional.Null = value == nil
+	if value == nil {
+		var zero T
+		optional.Value = zero
+		return nil
+	}
+	return state.setValue(reflect.ValueOf(&optional.Value).Elem(), value)
+}
+
+// asOptional returns target as an optionalValue, if it is one.
+func asOptional(target reflect.Value) (optionalValue, bool) {
+	if target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			if _, ok := reflect.Zero(target.Type()).Interface().(optionalValue); !ok {
+				return nil, false
+			}
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		optional, ok := target.Interface().(optionalValue)
+		return optional, ok
+	}
+	if !target.CanAddr() {
+		return nil, false
+	}
+	optional, ok := target.Addr().Interface().(optionalValue)
+	return optional, ok
+}
+
+// isOptionalType returns whether or not fields of type t are Optional
+// wrappers.
+func isOptionalType(t reflect.Type) bool {
+	optionalType := reflect.TypeOf((*optionalValue)(nil)).Elem()
+	return t.Implements(optionalType) || reflect.PointerTo(t).Implements(optionalType)
+}
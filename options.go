@@ -0,0 +1,114 @@
+package web_request_readers
+
+import (
+	gocontext "context"
+	"log/slog"
+
+	"github.com/stretchr/goweb/context"
+)
+
+const optionsDataKey = "options"
+
+// DefaultTagName is the struct tag that field keys and options are
+// read from, unless Options.TagName overrides it.
+const DefaultTagName = "request"
+
+// Options overrides decoding behavior for a single request, so that a
+// gateway hosting several APIs in one process can use different rules
+// on different routes.  Zero fields leave the usual behavior alone.
+// Options are attached to a request with SetRequestOptions or
+// WithOptions, and are read by ParseBody and by the UnmarshalParams
+// variants that know about the request (UnmarshalRequestParams, Bind,
+// and UnmarshalParamsCtx).
+type Options struct {
+	// Coercions replaces the Unmarshaler's Coercions.
+	Coercions Coercions
+
+	// MaxBodySize is the maximum number of bytes ParseBody will
+	// read from the request body.
+	MaxBodySize int64
+
+	// KeepRawBody makes ParseBody keep the bytes of the request
+	// body, so that RawBody can return them after the body has
+	// been parsed, and leaves request.Body readable from the start
+	// for later readers.  The whole body is held in memory, so it
+	// is best combined with MaxBodySize.
+	KeepRawBody bool
+
+	// HoneypotFields are the names of hidden form fields that people
+	// leave empty but bots tend to fill in.  If any of them has a
+	// value, ParseBody and ParseParams return a SpamDetected error;
+	// empty ones are removed from the params.
+	HoneypotFields []string
+
+	// TagName replaces "request" as the struct tag that field keys
+	// and options are read from, e.g. "v2" for
+	//
+	//	Name string `request:"name" v2:"full_name"`
+	TagName string
+
+	// Logger, if it is set, is used to log decode decisions at
+	// Debug level: how ParseBody decoded the body, defaults that
+	// were applied, unknown keys, and values that were rejected.
+	// It replaces the Unmarshaler's Logger.
+	Logger *slog.Logger
+}
+
+// optionsKey is the context.Context key for Options.
+type optionsKey struct{}
+
+// WithOptions returns a copy of ctx carrying opts.  It can be used to
+// attach options in net/http middleware, before goweb sees the
+// request, or to pass them to UnmarshalParamsCtx.
+func WithOptions(ctx gocontext.Context, opts Options) gocontext.Context {
+	return gocontext.WithValue(ctx, optionsKey{}, opts)
+}
+
+// SetRequestOptions attaches opts to a request.  It takes precedence
+// over options attached to the request's context with WithOptions.
+func SetRequestOptions(ctx context.Context, opts Options) {
+	ctx.Data().Set(optionsDataKey, opts)
+}
+
+// RequestOptions returns the options attached to a request, either by
+// SetRequestOptions or by WithOptions on the request's context.
+func RequestOptions(ctx context.Context) (Options, bool) {
+	if opts, ok := ctx.Data()[optionsDataKey].(Options); ok {
+		return opts, true
+	}
+	if request := ctx.HttpRequest(); request != nil {
+		opts, ok := request.Context().Value(optionsKey{}).(Options)
+		return opts, ok
+	}
+	return Options{}, false
+}
+
+// options returns the options for the current unmarshal, if any.
+func (state *unmarshalState) options() (Options, bool) {
+	if state.goCtx != nil {
+		if opts, ok := state.goCtx.Value(optionsKey{}).(Options); ok {
+			return opts, true
+		}
+	}
+	if state.ctx != nil {
+		return RequestOptions(state.ctx)
+	}
+	return Options{}, false
+}
+
+// applyOptions applies per-request options to the state.
+func (state *unmarshalState) applyOptions() {
+	opts, ok := state.options()
+	if !ok {
+		return
+	}
+	if opts.Coercions != 0 {
+		state.coercions = opts.Coercions.resolve()
+	}
+	if opts.TagName != "" {
+		state.tagName = opts.TagName
+	}
+	if opts.Logger != nil {
+		state.logger = opts.Logger
+	}
+}
@@ -0,0 +1,89 @@
+// Package otel records OpenTelemetry spans for web_request_readers'
+// ParseBody and UnmarshalParams.  Installing it once, e.g.
+//
+//	otel.Install(otelglobal.GetTracerProvider())
+//
+// makes every call create a span, as a child of the request's span
+// (for ParseBody) or of the context passed to UnmarshalParamsCtx (for
+// UnmarshalParams), without changing any call sites.
+//
+// Spans have these attributes, where they apply:
+//
+//	web_request_readers.content_type  the request's media type
+//	web_request_readers.body_size     the number of bytes read from the body
+//	web_request_readers.target_type   the Go type being unmarshalled to
+//	web_request_readers.error_class   web_request_readers.ErrorClass of the error
+//	error.type                        the Go type of the error
+//
+// Failed spans have an Error status described by the error class.
+// Error messages aren't recorded, since they may quote request values
+// such as passwords.
+package otel
+
+import (
+	gocontext "context"
+	"fmt"
+
+	web_request_readers "github.com/Radiobox/web_request_readers"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer that spans are
+// recorded with.
+const InstrumentationName = "github.com/Radiobox/web_request_readers"
+
+// Attribute keys set on spans.
+const (
+	ContentTypeKey = attribute.Key("web_request_readers.content_type")
+	BodySizeKey    = attribute.Key("web_request_readers.body_size")
+	TargetTypeKey  = attribute.Key("web_request_readers.target_type")
+	ErrorClassKey  = attribute.Key("web_request_readers.error_class")
+	ErrorTypeKey   = attribute.Key("error.type")
+)
+
+// Install makes web_request_readers record spans with a tracer from
+// provider.  It replaces any Tracer that was set before.
+func Install(provider trace.TracerProvider) {
+	web_request_readers.SetTracer(NewTracer(provider))
+}
+
+// NewTracer returns a web_request_readers.Tracer that records spans
+// with a tracer from provider, for callers that want to combine it
+// with their own Tracer.
+func NewTracer(provider trace.TracerProvider) web_request_readers.Tracer {
+	return tracer{tracer: provider.Tracer(InstrumentationName)}
+}
+
+type tracer struct {
+	tracer trace.Tracer
+}
+
+func (t tracer) StartParseBody(ctx gocontext.Context, mime string) func(size int, err error) {
+	_, span := t.tracer.Start(ctx, "web_request_readers.ParseBody",
+		trace.WithAttributes(ContentTypeKey.String(mime)))
+	return func(size int, err error) {
+		span.SetAttributes(BodySizeKey.Int(size))
+		end(span, err)
+	}
+}
+
+func (t tracer) StartUnmarshal(ctx gocontext.Context, target interface{}) func(err error) {
+	_, span := t.tracer.Start(ctx, "web_request_readers.UnmarshalParams",
+		trace.WithAttributes(TargetTypeKey.String(fmt.Sprintf("%T", target))))
+	return func(err error) {
+		end(span, err)
+	}
+}
+
+// end records the class and type of err, if there is one, and ends
+// span.  The message is left out, since it may quote request values.
+func end(span trace.Span, err error) {
+	if err != nil {
+		class := web_request_readers.ErrorClass(err)
+		span.SetAttributes(ErrorClassKey.String(class), ErrorTypeKey.String(fmt.Sprintf("%T", err)))
+		span.SetStatus(codes.Error, class)
+	}
+	span.End()
+}
@@ -0,0 +1,154 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/stretchr/objx"
+)
+
+// InvalidParam is the error returned by ParsePagination and
+// ParseBatchHints for a parameter that isn't a usable number.
+type InvalidParam struct {
+	// Param is the name of the parameter, e.g. "page".
+	Param string
+
+	// Message describes the problem, e.g. "Parameter page must be
+	// at least 1".
+	Message string
+
+	// Err is the error from parsing the value, if there was one.
+	Err error
+}
+
+// Error returns the error message for an InvalidParam error.
+func (err InvalidParam) Error() string {
+	return err.Message
+}
+
+// Unwrap returns the error from parsing the value, if there was one.
+func (err InvalidParam) Unwrap() error {
+	return err.Err
+}
+
+// DefaultMaxPageSize is the largest page size that ParsePagination
+// allows.
+const DefaultMaxPageSize = 1000
+
+// PageLimits are the server's rules for the pages of a list request.
+type PageLimits struct {
+	// DefaultPageSize is used when the request doesn't give a page
+	// size.  Zero defaults to MaxPageSize, or to 1 if there is no
+	// maximum.
+	DefaultPageSize int
+
+	// MaxPageSize is the largest page size that clients may ask
+	// for.  Larger sizes are clamped to it, with a warning.  Zero
+	// leaves the page size uncapped.
+	MaxPageSize int
+}
+
+// A Page describes the page of results that a list request asked for.
+type Page struct {
+	// Page is the 1-based page number.
+	Page int
+
+	// PageSize is the number of results per page.
+	PageSize int
+
+	// Offset is the number of results to skip, i.e. (Page - 1) *
+	// PageSize.
+	Offset int
+
+	// Limit is the maximum number of results to return.  It is
+	// always the same as PageSize, but reads better in queries.
+	Limit int
+
+	// Warnings describe the values that were clamped to a maximum,
+	// as for BatchHints.Warnings.
+	Warnings []string
+}
+
+// ParsePagination reads "page" and "page_size" from a set of
+// parameters and returns the Page they describe.  Missing values
+// default to the first page and defaultPageSize, and page sizes are
+// clamped to DefaultMaxPageSize.  See ParsePageLimits.
+func ParsePagination(params objx.Map, defaultPageSize int) (*Page, error) {
+	return ParsePageLimits(params, PageLimits{DefaultPageSize: defaultPageSize, MaxPageSize: DefaultMaxPageSize})
+}
+
+// ParsePageLimits reads "page" and "page_size" from a set of
+// parameters and returns the Page they describe, within limits.
+// Missing values default to the first page and limits.DefaultPageSize,
+// and page sizes above limits.MaxPageSize are clamped to it, with a
+// warning.
+//
+// The values may be strings (e.g. from ParseBody's single-value form
+// flattening), []string (e.g. raw query parameters, in which case the
+// first value is used and extra values are ignored), or numbers (e.g.
+// from a JSON body).  Any other type, a page or page size below 1, or
+// a page so large that its offset would overflow, results in an
+// InvalidParam error.
+func ParsePageLimits(params objx.Map, limits PageLimits) (*Page, error) {
+	page := &Page{Page: 1}
+	hints := &BatchHints{}
+	var err error
+	if page.PageSize, err = hints.clamp(params, "page_size", limits.DefaultPageSize, limits.MaxPageSize); err != nil {
+		return nil, err
+	}
+	page.Warnings = hints.Warnings
+	if pageVal, ok := params["page"]; ok {
+		pageNum, err := intParam("page", pageVal)
+		if err != nil {
+			return nil, err
+		}
+		if pageNum < 1 {
+			return nil, InvalidParam{Param: "page", Message: "Parameter page must be at least 1"}
+		}
+		page.Page = pageNum
+	}
+	if page.Page-1 > math.MaxInt/page.PageSize {
+		return nil, InvalidParam{Param: "page", Message: fmt.Sprintf("Parameter page must be at most %d", math.MaxInt/page.PageSize+1)}
+	}
+	page.Offset = (page.Page - 1) * page.PageSize
+	page.Limit = page.PageSize
+	return page, nil
+}
+
+// TotalPages returns the number of pages needed to show count
+// results.  There is always at least one page, even if it's empty.
+func (page *Page) TotalPages(count int) int {
+	if page.PageSize < 1 || count <= page.PageSize {
+		return 1
+	}
+	return (count + page.PageSize - 1) / page.PageSize
+}
+
+// LinkHeader renders an RFC 5988 Link header value with first, prev,
+// next, and last links for a list of count results.  Each link is a
+// copy of base with its page and page_size query parameters replaced;
+// prev and next are left out on the first and last pages.
+func (page *Page) LinkHeader(base *url.URL, count int) string {
+	total := page.TotalPages(count)
+	links := make([]string, 0, 4)
+	addLink := func(pageNum int, rel string) {
+		link := *base
+		query := link.Query()
+		query.Set("page", strconv.Itoa(pageNum))
+		query.Set("page_size", strconv.Itoa(page.PageSize))
+		link.RawQuery = query.Encode()
+		links = append(links, "<"+link.String()+`>; rel="`+rel+`"`)
+	}
+	addLink(1, "first")
+	if page.Page > 1 {
+		addLink(page.Page-1, "prev")
+	}
+	if page.Page < total {
+		addLink(page.Page+1, "next")
+	}
+	addLink(total, "last")
+	return strings.Join(links, ", ")
+}
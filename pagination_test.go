@@ -0,0 +1,61 @@
+package web_request_readers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/objx"
+)
+
+func TestParsePagination(t *testing.T) {
+	for _, test := range []struct {
+		params   objx.Map
+		offset   int
+		limit    int
+		warnings int
+	}{
+		{objx.Map{}, 0, 20, 0},
+		{objx.Map{"page": "3", "page_size": []string{"10", "50"}}, 20, 10, 0},
+		{objx.Map{"page": 2.0, "page_size": 5.0}, 5, 5, 0},
+		{objx.Map{"page_size": "5000"}, 0, DefaultMaxPageSize, 1},
+	} {
+		page, err := ParsePagination(test.params, 20)
+		if err != nil {
+			t.Errorf("%v: %v", test.params, err)
+			continue
+		}
+		if page.Offset != test.offset || page.Limit != test.limit || len(page.Warnings) != test.warnings {
+			t.Errorf("%v: unexpected page %+v", test.params, page)
+		}
+	}
+}
+
+func TestParsePaginationRejectsBadPages(t *testing.T) {
+	for _, params := range []objx.Map{
+		{"page": "abc"},
+		{"page": "0"},
+		{"page_size": "-1"},
+		{"page": 1.5},
+		{"page": []string{}},
+		{"page": true},
+		{"page": "9223372036854775807", "page_size": "100"},
+	} {
+		page, err := ParsePagination(params, 20)
+		var invalid InvalidParam
+		if !errors.As(err, &invalid) {
+			t.Errorf("%v: expected an InvalidParam, got %+v, %v", params, page, err)
+			continue
+		}
+		if status := SuggestedStatus(err); status != http.StatusBadRequest {
+			t.Errorf("%v: status %d", params, status)
+		}
+	}
+}
+
+func TestParsePageLimitsWithoutMaximum(t *testing.T) {
+	page, err := ParsePageLimits(objx.Map{"page_size": "5000"}, PageLimits{DefaultPageSize: 20})
+	if err != nil || page.Limit != 5000 || page.Warnings != nil {
+		t.Errorf("unexpected page %+v, %v", page, err)
+	}
+}
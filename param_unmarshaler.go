@@ -0,0 +1,460 @@
+package web_request_readers
+
+import (
+	gocontext "context"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stretchr/goweb/context"
+)
+
+// An Unmarshaler unmarshals request params to structs, using its own
+// set of options.  The zero value is ready to use, and behaves the
+// same as UnmarshalParams did before options existed.
+type Unmarshaler struct {
+	// KeyNormalizers is an ordered pipeline of functions that are
+	// applied to every key in the request params, and to every key
+	// that a struct field is looking for, before the two are
+	// compared.  This allows unusual client conventions (e.g.
+	// "X-" prefixed keys or camelCase keys) to match fields without
+	// needing custom tags everywhere.  Request keys that normalize
+	// to the same key are repeated keys, and are handled according
+	// to Config.DuplicateKeys; either way, the value of the key that
+	// is already normalized (or else the lowest key) is used.
+	KeyNormalizers []KeyNormalizer
+
+	// ReplayDefaults causes default values (from
+	// DefaultValueCreator and ContextDefaultValueCreator) to be
+	// written back into the params map under their request keys,
+	// so that code reading the raw params afterwards (logging,
+	// analytics, request signing) sees the effective values rather
+	// than just what the client sent.
+	ReplayDefaults bool
+
+	// MixedArrays decides how request arrays with elements of
+	// different types are unmarshalled to typed slices.
+	MixedArrays MixedArrayPolicy
+
+	// NumericKeySlices allows objects whose keys are the indexes
+	// 0 through n-1 (e.g. {"0": "a", "1": "b"}, which some form
+	// encoders produce) to be unmarshalled to slice fields.
+	NumericKeySlices bool
+
+	// Patch makes every unmarshal behave like
+	// UnmarshalParamsPatch.
+	Patch bool
+
+	// Coercions lists the type coercions that are allowed when a
+	// request value's type doesn't match its field's type.  The
+	// zero value means DefaultCoercions.  Individual fields can
+	// override this with the "weak" and "strict" tag options.
+	Coercions Coercions
+
+	// KeyMatcher decides which request keys fields without a
+	// request, response, or db tag may be read from.  A nil
+	// KeyMatcher means LowerKeys.
+	KeyMatcher KeyMatcher
+
+	// PrefixSeparator is placed between a prefix and the inner
+	// field keys of a struct tagged with the "prefix" option.  An
+	// empty PrefixSeparator means "_".
+	PrefixSeparator string
+
+	// DefaultsSatisfyRequired stops required fields that were
+	// given a default value from being reported in MissingFields.
+	DefaultsSatisfyRequired bool
+
+	// DurationUnit is the unit that plain numbers are read in for
+	// time.Duration fields, e.g. time.Millisecond.  A zero
+	// DurationUnit means time.Second.  Individual fields can
+	// override this with the "duration" tag option.
+	DurationUnit time.Duration
+
+	// SkipUnscopedFields causes values for fields that the caller
+	// doesn't have the scope to set to be ignored, rather than
+	// reported as ForbiddenFields.  See UnmarshalParamsScoped.
+	SkipUnscopedFields bool
+
+	// TruncateStrings causes strings that are longer than their
+	// field's maxlen option to be truncated, rather than reported
+	// as errors.
+	TruncateStrings bool
+
+	// TruncateFloats causes numbers with a fractional part to be
+	// truncated when they are assigned to integer fields, rather
+	// than reported as RangeErrors.  Individual fields can allow
+	// truncation with the "truncate" tag option.
+	TruncateFloats bool
+
+	// Strictness decides whether Coercions and TruncateFloats
+	// apply.  The zero value, Lenient, means they do.
+	Strictness Strictness
+
+	// RejectUnexportedTags causes unexported fields with a request
+	// tag to be reported as UnexportedField errors, rather than
+	// silently ignored.  A tag on an unexported field is almost
+	// always a mistake (e.g. a lowercase field name), which would
+	// otherwise only show up as a field that never gets set.
+	RejectUnexportedTags bool
+
+	// Messages renders the messages of FieldErrors, e.g. in the
+	// request's locale.  Custom templates from the msg option or
+	// FieldMessages still take precedence.  Messages are rendered
+	// with the context passed to UnmarshalParamsCtx, or with the
+	// request's context for UnmarshalRequestParams.
+	Messages MessageProvider
+
+	// MaxDepth is the maximum number of nested structs that a
+	// request may fill in, counting from the target.  A zero
+	// MaxDepth means DefaultMaxDepth, and a negative MaxDepth
+	// means no limit.  Params that contain themselves are always
+	// rejected.  See NestingError.
+	MaxDepth int
+
+	// Debug causes a DebugTrace of how every field was resolved to
+	// be collected in Result.Trace.  It slows unmarshalling down,
+	// so it is meant for tracking down fields that mysteriously
+	// stay zero.
+	Debug bool
+
+	// Logger, if it is set, is used to log decode decisions at
+	// Debug level: defaults that were applied, unknown keys, and
+	// values that were rejected, with the field and error as
+	// attributes.  Rejected values are logged by type and length
+	// only, since they may be secrets.  Options.Logger overrides it
+	// for a request.
+	Logger *slog.Logger
+}
+
+// A Result describes what happened during a single unmarshal.
+type Result struct {
+	// Defaults maps the request keys of fields that were missing
+	// from the request to the default values that were applied to
+	// them.  Keys of nested fields are dotted paths, e.g.
+	// "address.country", as in MissingFields.  It is nil if no
+	// defaults were applied.
+	Defaults map[string]interface{}
+
+	// ChangedFields lists the names of the struct fields that had
+	// a value in the request, in struct order.
+	ChangedFields []string
+
+	// Trace records how each field was resolved.  It is only
+	// collected (i.e. non-nil) when Unmarshaler.Debug is set.
+	Trace *DebugTrace
+
+	// DuplicateKeys lists the request keys that had several form
+	// values for a field that only holds one, and the normalized
+	// keys that several request keys were normalized to (see
+	// Unmarshaler.KeyNormalizers).  It is only filled in when
+	// Config.DuplicateKeys is DuplicateKeysReport.
+	DuplicateKeys []string
+}
+
+// DefaultUnmarshaler is the Unmarshaler used by the package-level
+// UnmarshalParams function.
+var DefaultUnmarshaler = new(Unmarshaler)
+
+// normalizeKey runs key through the unmarshaler's KeyNormalizers.
+func (unmarshaler *Unmarshaler) normalizeKey(key string) string {
+	for _, normalizer := range unmarshaler.KeyNormalizers {
+		key = normalizer(key)
+	}
+	return key
+}
+
+// unmarshalState stores the state of a single call to
+// Unmarshaler.UnmarshalParams.
+type unmarshalState struct {
+	unmarshaler *Unmarshaler
+	params      map[string]interface{}
+	missing     *MissingFields
+	forbidden   *ForbiddenFields
+
+	// allowed holds the request keys passed to
+	// UnmarshalParamsAllowed, or nil if every field may be set.
+	allowed map[string]bool
+
+	// scopes holds the scopes passed to UnmarshalParamsScoped.
+	scopes []string
+
+	// ctx is the request that params came from, if the caller
+	// provided it.
+	ctx context.Context
+
+	// goCtx is the context.Context passed to UnmarshalParamsCtx,
+	// if any.
+	goCtx gocontext.Context
+
+	result *Result
+
+	// recordChanges is true when result.ChangedFields is needed,
+	// i.e. for UnmarshalParamsResult, ApplyPatch, and targets that
+	// are ChangeRecorders.
+	recordChanges bool
+
+	// missingFields, forbiddenFields, and ownResult are what
+	// missing, forbidden, and result point to, unless they are
+	// shared with a parent state, so that a pooled state needs no
+	// allocations of its own.
+	missingFields   MissingFields
+	forbiddenFields ForbiddenFields
+	ownResult       Result
+
+	// changed maps the names of the fields of the struct being
+	// unmarshalled that were set from the request to their new
+	// values.  It is only tracked (i.e. non-nil) for a
+	// CrossValidator.
+	changed map[string]interface{}
+
+	// consumed holds the keys in params that were matched by a
+	// field, so that extra keys can be reported by name.
+	consumed map[string]bool
+
+	// discriminator is the key that chose the concrete type of
+	// the polymorphic value currently being set.  It doesn't count
+	// as an extra param if the concrete type has no field for it.
+	discriminator string
+
+	// messages holds the target's custom error message templates,
+	// if it implements FieldMessages.
+	messages map[string]string
+
+	// coercions is the set of coercions allowed for the value
+	// currently being set.
+	coercions Coercions
+
+	// stringValues is true when every value in params arrived as a
+	// string, as in query strings and headers, so that strings may
+	// be coerced to numbers and booleans whatever the strictness.
+	stringValues bool
+
+	// defaultRequired is the value of DefaultRequired when the
+	// unmarshal started.
+	defaultRequired bool
+
+	// duplicateKeys is the value of Config.DuplicateKeys when the
+	// unmarshal started.
+	duplicateKeys DuplicateKeyPolicy
+
+	// logger is where decode decisions are logged, if anywhere.
+	logger *slog.Logger
+
+	// tracer is the value of Config.Tracer when the unmarshal
+	// started.
+	tracer Tracer
+
+	// tagName is the struct tag that field keys and options are
+	// read from.
+	tagName string
+
+	// durationUnit is the unit for plain numbers assigned to the
+	// time.Duration currently being set.
+	durationUnit time.Duration
+
+	// rawBytes is true when a string assigned to the []byte
+	// currently being set should be used as it is, rather than
+	// decoded as base64.  See the "raw" tag option.
+	rawBytes bool
+
+	// exactNumbers is true when floating-point numbers should be
+	// rejected for the numeric converter currently being used.
+	// See the "exact" tag option.
+	exactNumbers bool
+
+	// truncate is true when the field currently being set has the
+	// "truncate" tag option.
+	truncate bool
+
+	// strict is true when the unmarshal is Strict.  See Strictness.
+	strict bool
+
+	// decoder is the ParsedContent.Decoder of the body that params
+	// came from, when it is known without ctx.  See BindRequest.
+	decoder string
+
+	// request is the request that params came from, when it is
+	// known without ctx.  See BindRequest.
+	request *http.Request
+
+	// ignoreExtra is true when params may hold keys that no field
+	// asked for, such as the headers of a request.
+	ignoreExtra bool
+
+	// prefix is prepended to the request keys of the fields
+	// currently being unmarshalled, and fieldPath to their names.
+	// See the "prefix" tag option.
+	prefix    string
+	fieldPath string
+
+	// fieldName is the request key of the field currently being
+	// set, and goFieldName is its struct field name.
+	fieldName   string
+	goFieldName string
+
+	// ancestors holds the map pointers of the params of every
+	// struct above the one being unmarshalled, to catch cycles and
+	// limit nesting.
+	ancestors []uintptr
+
+	// keyPath is the dotted path of request keys leading to the
+	// nested struct being unmarshalled, e.g. "address.".
+	keyPath string
+
+	// patch is true when only the keys present in params should
+	// be applied.  See UnmarshalParamsPatch.
+	patch bool
+
+	// keys maps normalized request keys to the original keys in
+	// params.  It is nil when there are no KeyNormalizers.
+	keys map[string]string
+
+	// keyCollisions lists, in sorted order, the normalized keys in
+	// keys that several request keys were normalized to.
+	keyCollisions []string
+
+	// foldedKeys maps case-folded, normalized request keys to the
+	// original keys in params.  It is built the first time a
+	// case-insensitive lookup happens.
+	foldedKeys map[string]string
+}
+
+// statePool holds unmarshalStates for reuse, since unmarshalling a
+// flat struct otherwise allocates little but its state.
+var statePool = sync.Pool{New: func() interface{} { return new(unmarshalState) }}
+
+// maxPooledKeys is the largest consumed map that is kept for reuse,
+// so that one huge request doesn't make clearing slow for the rest.
+const maxPooledKeys = 64
+
+func (unmarshaler *Unmarshaler) newState(ctx context.Context, params map[string]interface{}) *unmarshalState {
+	current := CurrentConfig()
+	state := statePool.Get().(*unmarshalState)
+	consumed := state.consumed
+	if consumed == nil {
+		consumed = make(map[string]bool, len(params))
+	}
+	*state = unmarshalState{
+		unmarshaler:     unmarshaler,
+		params:          params,
+		ctx:             ctx,
+		patch:           unmarshaler.Patch,
+		coercions:       unmarshaler.Coercions.resolve(),
+		consumed:        consumed,
+		tagName:         DefaultTagName,
+		defaultRequired: current.DefaultRequired,
+		duplicateKeys:   current.DuplicateKeys,
+		tracer:          current.Tracer,
+		logger:          unmarshaler.Logger,
+	}
+	state.missing = &state.missingFields
+	state.forbidden = &state.forbiddenFields
+	state.result = &state.ownResult
+	if unmarshaler.Debug {
+		state.result.Trace = new(DebugTrace)
+	}
+	if len(unmarshaler.KeyNormalizers) > 0 {
+		state.keys = make(map[string]string, len(params))
+		for key := range params {
+			normalized := unmarshaler.normalizeKey(key)
+			if addKey(state.keys, normalized, key) && !containsString(state.keyCollisions, normalized) {
+				state.keyCollisions = append(state.keyCollisions, normalized)
+			}
+		}
+		sort.Strings(state.keyCollisions)
+	}
+	return state
+}
+
+// release returns a state to statePool.  It must only be called once
+// nothing refers to the state, its missing and forbidden fields, or
+// its own result; errors copy what they need, so they are fine.
+func (state *unmarshalState) release() {
+	consumed := state.consumed
+	if len(consumed) > maxPooledKeys {
+		consumed = nil
+	}
+	clear(consumed)
+	*state = unmarshalState{consumed: consumed}
+	statePool.Put(state)
+}
+
+// lookup finds the value in the request params for a field, trying
+// each of the field's keys in order.  If fold is true, keys are
+// compared without regard to case.
+func (state *unmarshalState) lookup(keys []string, fold bool) (interface{}, bool) {
+	for _, name := range keys {
+		if key, ok := state.findKey(name, fold); ok {
+			return state.params[key], true
+		}
+	}
+	return nil, false
+}
+
+// goContext returns the context.Context for the unmarshal, which is
+// context.Background() unless one was passed to UnmarshalParamsCtx.
+func (state *unmarshalState) goContext() gocontext.Context {
+	if state.goCtx == nil {
+		return gocontext.Background()
+	}
+	return state.goCtx
+}
+
+// countKeys returns the number of distinct request keys that match
+// any of keys, and marks them as consumed.  Request keys that were
+// normalized to the same key all match, since they are repeats of
+// one key rather than extra keys.
+func (state *unmarshalState) countKeys(keys []string, fold bool) int {
+	found := make(map[string]bool, len(keys))
+	for _, name := range keys {
+		if key, ok := state.findKey(name, fold); ok {
+			found[key] = true
+			state.consumed[key] = true
+			if normalized := state.unmarshaler.normalizeKey(key); containsString(state.keyCollisions, normalized) {
+				for other := range state.params {
+					if state.unmarshaler.normalizeKey(other) == normalized {
+						found[other] = true
+						state.consumed[other] = true
+					}
+				}
+			}
+		}
+	}
+	return len(found)
+}
+
+// addKey maps normalized to key in keys, and returns whether another
+// key was already mapped to it.  Colliding keys are resolved the same
+// way whatever order they come in: a key that is already normalized
+// wins, and otherwise the lowest key does.
+func addKey(keys map[string]string, normalized, key string) bool {
+	existing, collided := keys[normalized]
+	if !collided || key == normalized || existing != normalized && key < existing {
+		keys[normalized] = key
+	}
+	return collided
+}
+
+// findKey finds the key in params that matches name.
+func (state *unmarshalState) findKey(name string, fold bool) (string, bool) {
+	if fold {
+		if state.foldedKeys == nil {
+			state.foldedKeys = make(map[string]string, len(state.params))
+			for key := range state.params {
+				addKey(state.foldedKeys, strings.ToLower(state.unmarshaler.normalizeKey(key)), key)
+			}
+		}
+		key, ok := state.foldedKeys[strings.ToLower(state.unmarshaler.normalizeKey(name))]
+		return key, ok
+	}
+	if state.keys != nil {
+		key, ok := state.keys[state.unmarshaler.normalizeKey(name)]
+		return key, ok
+	}
+	_, ok := state.params[name]
+	return name, ok
+}
@@ -0,0 +1,109 @@
+package web_request_readers
+
+import (
+	"github.com/stretchr/goweb/context"
+)
+
+const parsedContentDataKey = "parsed_content"
+
+// ParamsDataKey is the default key that ParseBody caches a parsed
+// body under in ctx.Data().  Handlers have always been able to read
+// the parsed body from ctx.Data()["params"]; applications that use
+// that key for something else can move the cache with
+// SetParamsDataKey.
+const ParamsDataKey = "params"
+
+// paramsDataKey returns the key that parsed bodies are currently
+// cached under.
+func paramsDataKey() string {
+	return CurrentConfig().paramsDataKey()
+}
+
+// paramsDataKey returns the key that parsed bodies are cached under
+// with this configuration.
+func (c Config) paramsDataKey() string {
+	if c.ParamsDataKey != "" {
+		return c.ParamsDataKey
+	}
+	return ParamsDataKey
+}
+
+// SetParamsDataKey atomically changes the key in ctx.Data() that
+// ParseBody caches parsed bodies under.  An empty key restores
+// ParamsDataKey.  See Config.ParamsDataKey.
+func SetParamsDataKey(key string) {
+	updateConfig(func(c *Config) { c.ParamsDataKey = key })
+}
+
+// CachedParams returns the parsed body cached in ctx by ParseBody, or
+// by a parser that called CacheParams.  The second return value is
+// false if nothing has been cached.
+func CachedParams(ctx context.Context) (interface{}, bool) {
+	params, ok := ctx.Data()[paramsDataKey()]
+	return params, ok
+}
+
+// CacheParams caches a parsed body in ctx, so that ParseBody (and so
+// ParseParams and Bind) return it for the rest of the request.  It is
+// for parsers of bodies that ParseBody can't read itself, e.g.
+// jsonapi.ParseParams.
+func CacheParams(ctx context.Context, params interface{}) {
+	ctx.Data()[paramsDataKey()] = params
+}
+
+// InvalidateParsedBody removes the cached results of ParseBody (and
+// ParseParams, ParseDocuments, and RawBody) from ctx, so that the
+// next call parses the request body again.  Middleware that replaces the body
+// of a request that may already have been parsed, e.g. to decrypt or
+// decompress it, should call this after replacing it.
+func InvalidateParsedBody(ctx context.Context) {
+	data := ctx.Data()
+	delete(data, paramsDataKey())
+	delete(data, parsedContentDataKey)
+	delete(data, documentsDataKey)
+	delete(data, rawBodyDataKey)
+	delete(data, userAgentInjectedDataKey)
+}
+
+// Decoder names used in ParsedContent.Decoder.
+const (
+	JSONDecoder      = "json"
+	FormDecoder      = "form"
+	MultipartDecoder = "multipart"
+
+	// MergePatchDecoder and JSONPatchDecoder decode JSON bodies
+	// like JSONDecoder, but mark them as patch documents for
+	// ApplyPatch.
+	MergePatchDecoder = "merge-patch"
+	JSONPatchDecoder  = "json-patch"
+)
+
+// ParsedContent describes how ParseBody read a request body.
+type ParsedContent struct {
+	// MimeType is the media type from the Content-Type header,
+	// without parameters, e.g. "application/json".  It is empty
+	// if the request had no (or an unparseable) Content-Type.
+	MimeType string
+
+	// Charset and Boundary are the charset and boundary
+	// parameters from the Content-Type header, if it had them.
+	Charset  string
+	Boundary string
+
+	// Decoder names the decoder that handled the body, e.g.
+	// JSONDecoder.
+	Decoder string
+
+	// DuplicateKeys lists the paths of keys that were repeated
+	// within an object of a JSON body.  It is only filled in when
+	// Config.DuplicateKeys is DuplicateKeysReport.
+	DuplicateKeys []string
+}
+
+// ParsedContentOf returns the ParsedContent for a request whose body
+// has been parsed by ParseBody (or ParseParams, or Bind).  The second
+// return value is false if the body hasn't been parsed yet.
+func ParsedContentOf(ctx context.Context) (ParsedContent, bool) {
+	content, ok := ctx.Data()[parsedContentDataKey].(ParsedContent)
+	return content, ok
+}
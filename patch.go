@@ -0,0 +1,282 @@
+package web_request_readers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/stretchr/goweb/context"
+	"github.com/stretchr/objx"
+)
+
+// Content types for PATCH request bodies.
+const (
+	// MergePatchType is the content type of an RFC 7386 JSON Merge
+	// Patch: an object whose keys replace the target's values, with
+	// null meaning "remove".
+	MergePatchType = "application/merge-patch+json"
+
+	// JSONPatchType is the content type of an RFC 6902 JSON Patch:
+	// an array of operations.
+	JSONPatchType = "application/json-patch+json"
+)
+
+// A PatchOperation is a single operation from an RFC 6902 JSON Patch.
+type PatchOperation struct {
+	Op    string
+	Path  string
+	From  string
+	Value interface{}
+}
+
+// ApplyPatch applies a PATCH request body to target, an existing
+// model, and returns the names of the struct fields that changed (see
+// Result.ChangedFields).  Fields are matched to keys exactly as they
+// are by UnmarshalParams, and keys that aren't in the patch leave
+// their fields alone.
+//
+// Merge patches (and plain JSON or form bodies, which are treated the
+// same way) are applied with UnmarshalParamsPatch.  JSON Patches may
+// use the add, replace, remove, and test operations on object members,
+// e.g. "/address/street"; remove sets the field to its zero value.
+// Operations are applied in order, so a test sees the values set by
+// the operations before it, and a failed test applies nothing.  The
+// move and copy operations, and paths into arrays, are not supported.
+func ApplyPatch(ctx context.Context, target interface{}) ([]string, error) {
+	return DefaultUnmarshaler.ApplyPatch(ctx, target)
+}
+
+// ApplyPatch applies a PATCH request body to target using the
+// unmarshaler's options.  See the package-level ApplyPatch for
+// details.
+func (unmarshaler *Unmarshaler) ApplyPatch(ctx context.Context, target interface{}) ([]string, error) {
+	body, err := ParseBody(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var params objx.Map
+	if content, _ := ParsedContentOf(ctx); content.Decoder == JSONPatchDecoder {
+		ops, err := parsePatchOperations(body)
+		if err != nil {
+			return nil, err
+		}
+		tagName := DefaultTagName
+		if opts, ok := RequestOptions(ctx); ok && opts.TagName != "" {
+			tagName = opts.TagName
+		}
+		if params, err = unmarshaler.patchParams(ops, target, tagName); err != nil {
+			return nil, err
+		}
+	} else if params, _ = asParams(body); params == nil && body != nil {
+		return nil, WrongBodyShape{Kind: bodyKind(body), Value: body}
+	}
+
+	patcher := *unmarshaler
+	patcher.Patch = true
+	state := patcher.newState(ctx, params)
+	defer state.release()
+	state.recordChanges = true
+	err = patcher.unmarshal(state, target)
+	return state.result.ChangedFields, err
+}
+
+// parsePatchOperations reads the operations out of a parsed JSON
+// Patch body.
+func parsePatchOperations(body interface{}) ([]PatchOperation, error) {
+	elems, ok := body.([]interface{})
+	if !ok {
+		return nil, errors.New("A JSON Patch must be an array of operations")
+	}
+	ops := make([]PatchOperation, 0, len(elems))
+	for index, elem := range elems {
+		params, ok := asParams(elem)
+		if !ok {
+			return nil, fmt.Errorf("JSON Patch operation %d is not an object", index)
+		}
+		op := PatchOperation{Value: params["value"]}
+		op.Op, _ = params["op"].(string)
+		op.Path, _ = params["path"].(string)
+		op.From, _ = params["from"].(string)
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// patchParams converts JSON Patch operations to the equivalent merge
+// patch params.  Operations are applied in order, as RFC 6902 says, so
+// test operations see the values that earlier operations set, and
+// target's values for paths that nothing has set yet.  If a test
+// fails, nothing is applied.  Paths are matched to target's fields by
+// their tagName tags.
+func (unmarshaler *Unmarshaler) patchParams(ops []PatchOperation, target interface{}, tagName string) (objx.Map, error) {
+	params := make(objx.Map)
+	for index, op := range ops {
+		path, err := splitPointer(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("JSON Patch operation %d: %s", index, err)
+		}
+		switch op.Op {
+		case "add", "replace":
+			setPatchValue(params, path, op.Value)
+		case "remove":
+			setPatchValue(params, path, nil)
+		case "test":
+			if err := unmarshaler.testPatchValue(target, params, path, op.Value, tagName); err != nil {
+				return nil, fmt.Errorf("JSON Patch operation %d: %s", index, err)
+			}
+		default:
+			return nil, fmt.Errorf("JSON Patch operation %d: unsupported op %q", index, op.Op)
+		}
+	}
+	return params, nil
+}
+
+// splitPointer splits an RFC 6901 JSON Pointer into its unescaped
+// reference tokens.
+func splitPointer(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") || pointer == "/" {
+		return nil, fmt.Errorf("invalid path %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for index, token := range tokens {
+		tokens[index] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// setPatchValue sets the value at path in params, creating nested
+// objects as needed.
+func setPatchValue(params objx.Map, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := params[key].(objx.Map)
+		if !ok {
+			child = make(objx.Map)
+			params[key] = child
+		}
+		params = child
+	}
+	params[path[len(path)-1]] = value
+}
+
+// testPatchValue checks that the field of target at path holds value,
+// converted to the field's type, once the params that earlier
+// operations set are applied.
+func (unmarshaler *Unmarshaler) testPatchValue(target interface{}, params objx.Map, path []string, value interface{}, tagName string) error {
+	field := reflect.ValueOf(target)
+	var nullKey string
+	for _, key := range path {
+		for field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				// Keep going with a zero value, since earlier
+				// operations may have set the path.
+				if nullKey == "" {
+					nullKey = key
+				}
+				field = reflect.New(field.Type().Elem())
+			}
+			field = field.Elem()
+		}
+		if field.Kind() != reflect.Struct {
+			return fmt.Errorf("unsupported path at %s", key)
+		}
+		next, ok := unmarshaler.fieldByKey(field, tagName, key)
+		if !ok {
+			return fmt.Errorf("no field for %s", key)
+		}
+		field = next
+	}
+
+	current := field.Interface()
+	if patched, ok := patchedValue(params, path); ok {
+		converted := reflect.New(field.Type())
+		if patched != nil {
+			if err := unmarshaler.UnmarshalValue(patched, converted.Interface()); err != nil {
+				return fmt.Errorf("test failed: %s", err)
+			}
+		}
+		current = converted.Elem().Interface()
+	} else if nullKey != "" {
+		return fmt.Errorf("test failed: %s is null", nullKey)
+	}
+
+	expected := reflect.New(field.Type())
+	if value != nil {
+		if err := unmarshaler.UnmarshalValue(value, expected.Interface()); err != nil {
+			return fmt.Errorf("test failed: %s", err)
+		}
+	}
+	if !reflect.DeepEqual(expected.Elem().Interface(), current) {
+		return errors.New("test failed: values differ")
+	}
+	return nil
+}
+
+// patchedValue returns the value that earlier operations set at path
+// in params.  A path below a removed object is null.
+func patchedValue(params objx.Map, path []string) (interface{}, bool) {
+	for index, key := range path {
+		value, ok := params[key]
+		if !ok {
+			return nil, false
+		}
+		if index == len(path)-1 || value == nil {
+			return value, true
+		}
+		if params, ok = asParams(value); !ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// fieldByKey finds the field of a struct with the request key key,
+// looking through embedded structs.  Keys are matched the way
+// unmarshalling matches them: by the fields' tagName tags, the
+// unmarshaler's KeyMatcher for untagged fields, and its
+// KeyNormalizers.  Nil embedded pointers are skipped, since all of
+// their fields are already zero.
+func (unmarshaler *Unmarshaler) fieldByKey(structValue reflect.Value, tagName, key string) (reflect.Value, bool) {
+	structType := structValue.Type()
+	for i, meta := range fieldMetas(structType, tagName) {
+		fieldType := structType.Field(i)
+		if fieldType.Anonymous && embeddedStructType(fieldType) != nil {
+			embedded := structValue.Field(i)
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if field, ok := unmarshaler.fieldByKey(embedded, tagName, key); ok {
+				return field, true
+			}
+			continue
+		}
+		if fieldType.PkgPath != "" || meta.name == "-" {
+			continue
+		}
+		keys, fold := meta.keys, false
+		if !meta.tagged && unmarshaler.KeyMatcher != nil {
+			keys, fold = unmarshaler.untaggedKeys(fieldType.Name)
+			keys = append(keys, meta.keys[1:]...)
+		}
+		if unmarshaler.matchesKey(keys, key, fold) {
+			return structValue.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// matchesKey returns whether key, once normalized, is one of keys.  If
+// fold is true, keys are compared without regard to case.
+func (unmarshaler *Unmarshaler) matchesKey(keys []string, key string, fold bool) bool {
+	key = unmarshaler.normalizeKey(key)
+	for _, candidate := range keys {
+		candidate = unmarshaler.normalizeKey(candidate)
+		if candidate == key || fold && strings.EqualFold(candidate, key) {
+			return true
+		}
+	}
+	return false
+}
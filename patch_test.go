@@ -0,0 +1,108 @@
+package web_request_readers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Radiobox/web_request_readers/readertest"
+)
+
+type testPatchAddress struct {
+	Street string `request:"street"`
+	City   string `request:"city"`
+}
+
+type testPatchTarget struct {
+	A       int               `request:"a"`
+	B       string            `request:"b"`
+	Address *testPatchAddress `request:"address"`
+}
+
+func applyTestPatch(t *testing.T, target *testPatchTarget, patch string) ([]string, error) {
+	t.Helper()
+	request := readertest.JSONRequest(t, "PATCH", "/", patch)
+	request.Header.Set("Content-Type", JSONPatchType)
+	return ApplyPatch(readertest.NewContext(request), target)
+}
+
+func TestApplyPatchIsSequential(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		wantErr bool
+		want    testPatchTarget
+	}{
+		{
+			name:  "test sees an earlier replace",
+			patch: `[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":2},{"op":"replace","path":"/b","value":"y"}]`,
+			want:  testPatchTarget{A: 2, B: "y"},
+		},
+		{
+			name:    "test doesn't see the original value after a replace",
+			patch:   `[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":1},{"op":"replace","path":"/b","value":"y"}]`,
+			wantErr: true,
+			want:    testPatchTarget{A: 1, B: "x"},
+		},
+		{
+			name:    "failed test applies nothing after it",
+			patch:   `[{"op":"test","path":"/b","value":"nope"},{"op":"replace","path":"/a","value":5}]`,
+			wantErr: true,
+			want:    testPatchTarget{A: 1, B: "x"},
+		},
+		{
+			name:  "test sees a remove",
+			patch: `[{"op":"remove","path":"/b"},{"op":"test","path":"/b","value":""}]`,
+			want:  testPatchTarget{A: 1},
+		},
+		{
+			name:  "test sees an object added under a nil pointer",
+			patch: `[{"op":"add","path":"/address","value":{"street":"Main","city":"Springfield"}},{"op":"test","path":"/address/street","value":"Main"}]`,
+			want:  testPatchTarget{A: 1, B: "x", Address: &testPatchAddress{Street: "Main", City: "Springfield"}},
+		},
+		{
+			name:    "test of a path under a nil pointer fails",
+			patch:   `[{"op":"test","path":"/address/street","value":""}]`,
+			wantErr: true,
+			want:    testPatchTarget{A: 1, B: "x"},
+		},
+	}
+	for _, test := range tests {
+		target := testPatchTarget{A: 1, B: "x"}
+		_, err := applyTestPatch(t, &target, test.patch)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		if target.A != test.want.A || target.B != test.want.B ||
+			(target.Address == nil) != (test.want.Address == nil) ||
+			(target.Address != nil && *target.Address != *test.want.Address) {
+			t.Errorf("%s: got %+v, want %+v", test.name, target, test.want)
+		}
+	}
+}
+
+type testPatchNamed struct {
+	First    string `json:"first"`
+	LastName string
+}
+
+func TestApplyPatchMatchesKeysLikeUnmarshal(t *testing.T) {
+	unmarshaler := &Unmarshaler{KeyMatcher: SnakeKeys, KeyNormalizers: []KeyNormalizer{strings.ToLower}}
+	for _, test := range []struct {
+		patch string
+		valid bool
+	}{
+		{`[{"op":"test","path":"/first","value":"a"},{"op":"test","path":"/last_name","value":"b"}]`, true},
+		{`[{"op":"test","path":"/FIRST","value":"a"},{"op":"test","path":"/Last_Name","value":"b"}]`, true},
+		{`[{"op":"test","path":"/first","value":"z"}]`, false},
+		{`[{"op":"test","path":"/lastname","value":"b"}]`, false},
+	} {
+		request := readertest.JSONRequest(t, "PATCH", "/", test.patch)
+		request.Header.Set("Content-Type", JSONPatchType)
+		ctx := readertest.NewContext(request)
+		SetRequestOptions(ctx, Options{TagName: "json"})
+		target := testPatchNamed{First: "a", LastName: "b"}
+		if _, err := unmarshaler.ApplyPatch(ctx, &target); (err == nil) != test.valid {
+			t.Errorf("%s: unexpected error %v", test.patch, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package web_request_readers
+
+import (
+	"github.com/stretchr/goweb/context"
+	"github.com/stretchr/objx"
+)
+
+// UnmarshalMap is UnmarshalParams for callers that would rather not
+// depend on objx: params may be a plain map[string]interface{}, and
+// nested objects may be either plain maps or objx.Maps.
+//
+// Unmarshalling works on plain maps throughout; UnmarshalParams just
+// passes its objx.Map through to UnmarshalMap.  Only Unmarshaller
+// targets, whose interface predates plain maps, see params as an
+// objx.Map.
+func UnmarshalMap(params map[string]interface{}, target interface{}) error {
+	return DefaultUnmarshaler.UnmarshalMap(params, target)
+}
+
+// UnmarshalMap unmarshals a plain map to target using the
+// unmarshaler's options.  See the package-level UnmarshalMap for
+// details.
+func (unmarshaler *Unmarshaler) UnmarshalMap(params map[string]interface{}, target interface{}) error {
+	state := unmarshaler.newState(nil, params)
+	defer state.release()
+	return unmarshaler.unmarshal(state, target)
+}
+
+// ParseBodyMap is ParseBody for callers that would rather not depend
+// on objx.  The body may be of any shape, e.g. a JSON array, and every
+// object in it, including nested objects, is a plain
+// map[string]interface{}.  The result is a copy, so changing it
+// doesn't change the body cached by ParseBody.
+//
+// Bodies are still parsed and cached by ParseBody, since goweb keeps
+// request data in an objx.Map; ParseBodyMap only converts the result.
+func ParseBodyMap(ctx context.Context) (interface{}, error) {
+	body, err := ParseBody(ctx)
+	if err != nil || body == nil {
+		return nil, err
+	}
+	return ConvertObjxMapToMSI(body), nil
+}
+
+// ParseParamsMap is ParseParams for callers that would rather not
+// depend on objx: it returns a WrongBodyShape error unless the body
+// is an object, which is returned as a plain map as by ParseBodyMap.
+func ParseParamsMap(ctx context.Context) (map[string]interface{}, error) {
+	body, err := ParseBodyMap(ctx)
+	if err != nil || body == nil {
+		return nil, err
+	}
+	params, ok := body.(map[string]interface{})
+	if !ok {
+		return nil, WrongBodyShape{Kind: bodyKind(body), Value: body}
+	}
+	return params, nil
+}
+
+// ConvertObjxMapToMSI is the reverse of ConvertMSIToObjxMap: it
+// returns a copy of value with every objx.Map converted to a plain
+// map[string]interface{}.
+func ConvertObjxMapToMSI(value interface{}) interface{} {
+	switch src := value.(type) {
+	case objx.Map:
+		return ConvertObjxMapToMSI(map[string]interface{}(src))
+	case map[string]interface{}:
+		dest := make(map[string]interface{}, len(src))
+		for key, val := range src {
+			dest[key] = ConvertObjxMapToMSI(val)
+		}
+		return dest
+	case []interface{}:
+		dest := make([]interface{}, len(src))
+		for index, val := range src {
+			dest[index] = ConvertObjxMapToMSI(val)
+		}
+		return dest
+	}
+	return value
+}
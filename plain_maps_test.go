@@ -0,0 +1,50 @@
+package web_request_readers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Radiobox/web_request_readers/readertest"
+)
+
+func TestParseBodyMapAcceptsAnyShape(t *testing.T) {
+	ctx := readertest.NewContext(readertest.JSONRequest(t, "POST", "/", `[{"name": "bob", "address": {"city": "Oslo"}}]`))
+	body, err := ParseBodyMap(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	elems, ok := body.([]interface{})
+	if !ok || len(elems) != 1 {
+		t.Fatalf("unexpected body %#v", body)
+	}
+	elem, ok := elems[0].(map[string]interface{})
+	if !ok {
+		t.Fatalf("element is a %T, not a plain map", elems[0])
+	}
+	if _, ok := elem["address"].(map[string]interface{}); !ok {
+		t.Errorf("nested object is a %T, not a plain map", elem["address"])
+	}
+
+	var shape WrongBodyShape
+	if _, err := ParseParamsMap(ctx); !errors.As(err, &shape) || shape.Kind != "array" {
+		t.Errorf("ParseParamsMap accepted an array body: %v", err)
+	}
+}
+
+func TestUnmarshalMapWithPlainMaps(t *testing.T) {
+	unmarshaler := &Unmarshaler{ReplayDefaults: true}
+	params := map[string]interface{}{
+		"address": map[string]interface{}{"street": "1 Main St"},
+	}
+	var customer testCustomer
+	if err := unmarshaler.UnmarshalMap(params, &customer); err != nil {
+		t.Fatal(err)
+	}
+	if customer.Address.Street != "1 Main St" || customer.Address.Country != "US" {
+		t.Errorf("unexpected customer %+v", customer)
+	}
+	address := params["address"].(map[string]interface{})
+	if params["country"] != "EU" || address["country"] != "US" {
+		t.Errorf("defaults replayed as %v", params)
+	}
+}
@@ -0,0 +1,81 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// polymorphicType describes how to pick the concrete type for fields
+// of a registered interface type.
+type polymorphicType struct {
+	discriminator string
+	types         map[string]reflect.Type
+}
+
+var polymorphicTypes = make(map[reflect.Type]polymorphicType)
+
+// RegisterPolymorphic allows fields of the interface type iface to be
+// unmarshalled from request objects.  The value of the discriminator
+// key in each object picks the concrete type from types, e.g.
+//
+//	RegisterPolymorphic(reflect.TypeOf((*Shape)(nil)).Elem(), "kind", map[string]reflect.Type{
+//		"circle": reflect.TypeOf(Circle{}),
+//		"square": reflect.TypeOf(&Square{}),
+//	})
+//
+// The concrete types must be structs or pointers to structs that
+// implement iface; the object is unmarshalled to a new value of the
+// concrete type just like any other nested struct.  The discriminator
+// may, but doesn't have to, have a field of its own in the concrete
+// type.
+func RegisterPolymorphic(iface reflect.Type, discriminator string, types map[string]reflect.Type) {
+	if iface.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("RegisterPolymorphic: %s is not an interface type", iface))
+	}
+	for name, concrete := range types {
+		if !concrete.Implements(iface) {
+			panic(fmt.Sprintf("RegisterPolymorphic: %s (for %q) does not implement %s", concrete, name, iface))
+		}
+	}
+	polymorphicTypes[iface] = polymorphicType{discriminator: discriminator, types: types}
+}
+
+// setPolymorphic sets an interface-typed target to a new value of the
+// concrete type chosen by value's discriminator.  It returns false if
+// target's type isn't registered or value isn't an object.
+func (state *unmarshalState) setPolymorphic(target reflect.Value, value interface{}) (bool, error) {
+	polymorphic, ok := polymorphicTypes[target.Type()]
+	if !ok {
+		return false, nil
+	}
+	params, ok := asParams(value)
+	if !ok {
+		return false, nil
+	}
+	kind, ok := params[polymorphic.discriminator].(string)
+	if !ok {
+		return true, fmt.Errorf("Missing %s to choose the type of %s", polymorphic.discriminator, state.fieldName)
+	}
+	concrete, ok := polymorphic.types[kind]
+	if !ok {
+		return true, fmt.Errorf("Unknown %s %q for %s", polymorphic.discriminator, kind, state.fieldName)
+	}
+
+	var newValue, structValue reflect.Value
+	if concrete.Kind() == reflect.Ptr {
+		newValue = reflect.New(concrete.Elem())
+		structValue = newValue.Elem()
+	} else {
+		newValue = reflect.New(concrete).Elem()
+		structValue = newValue
+	}
+	previous := state.discriminator
+	state.discriminator = polymorphic.discriminator
+	err := state.setStruct(structValue, params)
+	state.discriminator = previous
+	if err != nil {
+		return true, err
+	}
+	target.Set(newValue)
+	return true, nil
+}
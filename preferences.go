@@ -0,0 +1,134 @@
+package web_request_readers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stretchr/goweb/context"
+)
+
+const preferencesDataKey = "preferences"
+
+// Values of Preferences.Return.
+const (
+	ReturnMinimal        = "minimal"
+	ReturnRepresentation = "representation"
+)
+
+// Preferences holds the preferences that a client sent in the Prefer
+// header (RFC 7240) of a request.  Preferences are hints; handlers
+// that honor one should say so with PreferenceApplied.
+type Preferences struct {
+	// Return is the value of the return preference: ReturnMinimal,
+	// ReturnRepresentation, or "" if the client didn't send one.
+	Return string
+
+	// Wait is the value of the wait preference, or 0 if the client
+	// didn't send one.
+	Wait time.Duration
+
+	// RespondAsync is true if the client sent respond-async.
+	RespondAsync bool
+
+	// Handling is the value of the handling preference: "strict",
+	// "lenient", or "".
+	Handling string
+
+	// All holds every preference the client sent, including the
+	// ones above, by lower-cased name.  Preferences without a
+	// value map to "".
+	All map[string]string
+}
+
+// ParsePreferences reads the Prefer headers of a request.  Malformed
+// preferences are ignored, as RFC 7240 requires, and only the first
+// instance of a repeated preference counts.  The result is cached in
+// ctx.Data().
+func ParsePreferences(ctx context.Context) *Preferences {
+	if preferences, ok := ctx.Data()[preferencesDataKey].(*Preferences); ok {
+		return preferences
+	}
+	preferences := &Preferences{All: make(map[string]string)}
+	for _, header := range ctx.HttpRequest().Header.Values("Prefer") {
+		for _, entry := range splitQuoted(header, ',') {
+			// Parameters after the first ";" aren't used by any
+			// of the registered preferences.
+			entry = splitQuoted(entry, ';')[0]
+			name, value, _ := strings.Cut(entry, "=")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if _, seen := preferences.All[name]; seen {
+				continue
+			}
+			value = unquote(strings.TrimSpace(value))
+			preferences.All[name] = value
+			switch name {
+			case "return":
+				preferences.Return = strings.ToLower(value)
+			case "wait":
+				if seconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+					preferences.Wait = time.Duration(seconds) * time.Second
+				}
+			case "respond-async":
+				preferences.RespondAsync = true
+			case "handling":
+				preferences.Handling = strings.ToLower(value)
+			}
+		}
+	}
+	ctx.Data().Set(preferencesDataKey, preferences)
+	return preferences
+}
+
+// PreferenceApplied adds a Preference-Applied header to
+// ResponseHeaders, telling the client that a preference was honored,
+// e.g. PreferenceApplied(ctx, "return", ReturnMinimal).  An empty
+// value sends just the name, as for respond-async.
+func PreferenceApplied(ctx context.Context, name, value string) {
+	if value != "" {
+		name += "=" + value
+	}
+	ResponseHeaders(ctx).Add("Preference-Applied", name)
+}
+
+// splitQuoted splits value on sep, except where sep is inside a
+// quoted string.
+func splitQuoted(value string, sep byte) []string {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, value[start:])
+}
+
+// unquote removes the quotes (and escapes) from a quoted string, and
+// returns anything else as it is.
+func unquote(value string) string {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return value
+	}
+	var unquoted strings.Builder
+	for i := 1; i < len(value)-1; i++ {
+		if value[i] == '\\' && i+1 < len(value)-1 {
+			i++
+		}
+		unquoted.WriteByte(value[i])
+	}
+	return unquoted.String()
+}
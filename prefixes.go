@@ -0,0 +1,88 @@
+package web_request_readers
+
+import (
+	"reflect"
+)
+
+// embeddedPrefix returns whether or not a field's own fields should
+// be read directly from the current params (as they are for embedded
+// structs), along with the key prefix to read them with.
+//
+// Anonymous struct fields, and anonymous pointers to structs, are
+// always flattened this way.  Other struct
+// fields are only flattened if they have the "prefix" tag option,
+// e.g.
+//
+//	type Order struct {
+//	    Billing  Address `request:"billing,prefix"`
+//	    Shipping Address `request:"shipping,prefix"`
+//	}
+//
+// would read Billing.Street from "billing_street" and Shipping.Street
+// from "shipping_street".  The same option on an embedded struct gives
+// its fields a prefix too, which avoids collisions between two
+// embedded structs with the same field names.
+func (state *unmarshalState) embeddedPrefix(fieldType reflect.StructField, meta *fieldMeta) (string, bool) {
+	if fieldType.Anonymous {
+		if embeddedStructType(fieldType) == nil {
+			return "", false
+		}
+	} else if fieldType.PkgPath != "" || fieldType.Type.Kind() != reflect.Struct {
+		return "", false
+	}
+	name, args := meta.name, meta.args
+	if !containsString(args, "prefix") {
+		return "", fieldType.Anonymous
+	}
+	separator := state.unmarshaler.PrefixSeparator
+	if separator == "" {
+		separator = "_"
+	}
+	return name + separator, true
+}
+
+// withPrefix adds a key prefix for the fields of an embedded or
+// prefixed struct, returning a function that restores the previous
+// prefix.
+func (state *unmarshalState) withPrefix(prefix string, fieldType reflect.StructField) func() {
+	previousPrefix, previousPath := state.prefix, state.fieldPath
+	state.prefix += prefix
+	if !fieldType.Anonymous {
+		state.fieldPath += fieldType.Name + "."
+	}
+	return func() {
+		state.prefix, state.fieldPath = previousPrefix, previousPath
+	}
+}
+
+// embeddedStructType returns the struct type of an anonymous field,
+// which may be embedded by value or by pointer, or nil if the field
+// is not a struct that can be flattened.  Pointers to unexported
+// structs are left alone, because reflect can't set their fields.
+func embeddedStructType(fieldType reflect.StructField) reflect.Type {
+	embeddedType := fieldType.Type
+	if embeddedType.Kind() == reflect.Ptr {
+		if fieldType.PkgPath != "" {
+			return nil
+		}
+		embeddedType = embeddedType.Elem()
+	}
+	if embeddedType.Kind() != reflect.Struct {
+		return nil
+	}
+	return embeddedType
+}
+
+// embeddedTarget returns the struct value to unmarshal the fields of
+// an embedded field to.  For a nil embedded pointer, this is a newly
+// allocated struct, and allocated is true; the caller should only
+// store it in the field if anything was set on it.
+func embeddedTarget(field reflect.Value) (target reflect.Value, allocated bool) {
+	if field.Kind() != reflect.Ptr {
+		return field, false
+	}
+	if !field.IsNil() {
+		return field.Elem(), false
+	}
+	return reflect.New(field.Type().Elem()).Elem(), true
+}
@@ -0,0 +1,235 @@
+// Package prometheus exposes Prometheus metrics about request parsing
+// and unmarshalling, fed by web_request_readers' OnBodyParsed and
+// OnUnmarshalled hooks.  Register it once at startup:
+//
+//	metrics, err := prometheus.Register(promclient.DefaultRegisterer, "myapp")
+//
+// The metrics are:
+//
+//	<namespace>_request_body_parse_seconds   histogram by content_type and error_class
+//	<namespace>_request_body_size_bytes      histogram by content_type
+//	<namespace>_request_unmarshal_seconds    histogram by target
+//	<namespace>_request_unmarshal_errors_total
+//	                                         counter by target, error_class, field, and code
+//
+// error_class is web_request_readers.ErrorClass of the error ("" for
+// success), which makes it easy to alert on spikes in decode failures
+// that cause 4xx responses.  Labels that come from the request are
+// kept to a bounded set: content types that ParseBody doesn't support
+// are counted as "other", keys that didn't match any field are
+// counted with an empty field, and field paths are resolved against
+// the target's type, so that slice indexes are dropped (e.g.
+// "items[].price") and map keys, or anything else that isn't a struct
+// field, are replaced with "[*]" (e.g. "scores[*]").
+package prometheus
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	web_request_readers "github.com/Radiobox/web_request_readers"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds the collectors for request parsing and unmarshalling.
+// It is itself a prometheus.Collector.
+type Metrics struct {
+	ParseDuration     *prom.HistogramVec
+	BodySize          *prom.HistogramVec
+	UnmarshalDuration *prom.HistogramVec
+	UnmarshalErrors   *prom.CounterVec
+}
+
+// New creates the metrics, with names in namespace.  The metrics
+// aren't fed until Install is called.
+func New(namespace string) *Metrics {
+	return &Metrics{
+		ParseDuration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_body_parse_seconds",
+			Help:      "Time taken to decode request bodies.",
+			Buckets:   prom.DefBuckets,
+		}, []string{"content_type", "error_class"}),
+		BodySize: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_body_size_bytes",
+			Help:      "Size of decoded request bodies.",
+			Buckets:   prom.ExponentialBuckets(64, 4, 10),
+		}, []string{"content_type"}),
+		UnmarshalDuration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_unmarshal_seconds",
+			Help:      "Time taken to unmarshal request params to models.",
+			Buckets:   prom.DefBuckets,
+		}, []string{"target"}),
+		UnmarshalErrors: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "request_unmarshal_errors_total",
+			Help:      "Errors from unmarshalling request params, by field.",
+		}, []string{"target", "error_class", "field", "code"}),
+	}
+}
+
+// Register creates the metrics, registers them with registerer, and
+// installs them.
+func Register(registerer prom.Registerer, namespace string) (*Metrics, error) {
+	metrics := New(namespace)
+	if err := registerer.Register(metrics); err != nil {
+		return nil, err
+	}
+	metrics.Install()
+	return metrics, nil
+}
+
+// Install adds the hooks that feed the metrics.  Like
+// web_request_readers.OnBodyParsed, it should only be called during
+// initialization.
+func (metrics *Metrics) Install() {
+	web_request_readers.OnBodyParsed(metrics.bodyParsed)
+	web_request_readers.OnUnmarshalled(metrics.unmarshalled)
+}
+
+// Describe implements prometheus.Collector.
+func (metrics *Metrics) Describe(descs chan<- *prom.Desc) {
+	metrics.ParseDuration.Describe(descs)
+	metrics.BodySize.Describe(descs)
+	metrics.UnmarshalDuration.Describe(descs)
+	metrics.UnmarshalErrors.Describe(descs)
+}
+
+// Collect implements prometheus.Collector.
+func (metrics *Metrics) Collect(ch chan<- prom.Metric) {
+	metrics.ParseDuration.Collect(ch)
+	metrics.BodySize.Collect(ch)
+	metrics.UnmarshalDuration.Collect(ch)
+	metrics.UnmarshalErrors.Collect(ch)
+}
+
+func (metrics *Metrics) bodyParsed(mime string, size int, dur time.Duration, err error) {
+	contentType := contentTypeLabel(mime)
+	metrics.ParseDuration.WithLabelValues(contentType, web_request_readers.ErrorClass(err)).Observe(dur.Seconds())
+	metrics.BodySize.WithLabelValues(contentType).Observe(float64(size))
+}
+
+func (metrics *Metrics) unmarshalled(target interface{}, dur time.Duration, err error) {
+	targetType := fmt.Sprintf("%T", target)
+	metrics.UnmarshalDuration.WithLabelValues(targetType).Observe(dur.Seconds())
+	if err == nil {
+		return
+	}
+	class := web_request_readers.ErrorClass(err)
+	response, ok := web_request_readers.ResponseFor(err)
+	if !ok || len(response.Errors) == 0 {
+		metrics.UnmarshalErrors.WithLabelValues(targetType, class, "", "").Inc()
+		return
+	}
+	for _, entry := range response.Errors {
+		field := fieldLabel(reflect.TypeOf(target), entry.Field)
+		if entry.Code == "unknown" {
+			field = ""
+		}
+		metrics.UnmarshalErrors.WithLabelValues(targetType, class, field, entry.Code).Inc()
+	}
+}
+
+// sliceIndexes matches the indexes at the end of a field path
+// segment, like "[2]" in "items[2]".
+var sliceIndexes = regexp.MustCompile(`(\[\d+\])+$`)
+
+// fieldLabel returns the label for the field path of an error from
+// unmarshalling to a target of type target.  Segments are kept only
+// if they name a field of the struct they are in; slice indexes
+// become "[]", and the rest of a path from a map key or a segment
+// that can't be resolved becomes "[*]", since those come from the
+// client.
+func fieldLabel(target reflect.Type, field string) string {
+	var label strings.Builder
+	current := target
+	for _, segment := range strings.Split(field, ".") {
+		name := segment
+		indexes := 0
+		if match := sliceIndexes.FindString(segment); match != "" {
+			name = strings.TrimSuffix(segment, match)
+			indexes = strings.Count(match, "[")
+		}
+		current = derefType(current)
+		switch {
+		case name == "":
+			// A top-level slice, as for UnmarshalSlice.
+		case current != nil && current.Kind() == reflect.Struct:
+			fieldType, ok := structField(current, name)
+			if !ok {
+				label.WriteString("[*]")
+				return label.String()
+			}
+			if label.Len() > 0 {
+				label.WriteByte('.')
+			}
+			label.WriteString(name)
+			current = fieldType
+		case current != nil && current.Kind() == reflect.Map:
+			label.WriteString("[*]")
+			current = current.Elem()
+		default:
+			label.WriteString("[*]")
+			return label.String()
+		}
+		for ; indexes > 0; indexes-- {
+			if current = derefType(current); current == nil || (current.Kind() != reflect.Slice && current.Kind() != reflect.Array) {
+				label.WriteString("[*]")
+				return label.String()
+			}
+			label.WriteString("[]")
+			current = current.Elem()
+		}
+	}
+	return label.String()
+}
+
+// derefType returns t without any pointers, or nil if t is nil.
+func derefType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// structField returns the type of the field of structType that is
+// read from the request key name, looking through embedded structs.
+func structField(structType reflect.Type, name string) (reflect.Type, bool) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		if embedded := derefType(field.Type); field.Anonymous && embedded.Kind() == reflect.Struct {
+			// Prefixed embedded structs aren't resolved, so their
+			// fields are labelled "[*]".
+			if fieldType, ok := structField(embedded, name); ok {
+				return fieldType, true
+			}
+			continue
+		}
+		if key, _ := web_request_readers.NameAndArgs(field); key == name {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+// contentTypeLabel returns mime if ParseBody supports it, "none" if
+// it is empty, and "other" otherwise.
+func contentTypeLabel(mime string) string {
+	if mime == "" {
+		return "none"
+	}
+	for _, supported := range web_request_readers.SupportedMediaTypes {
+		if mime == supported {
+			return mime
+		}
+	}
+	return "other"
+}
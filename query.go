@@ -0,0 +1,154 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/stretchr/objx"
+)
+
+// ParseQuery parses the query string of u into params, applying the
+// same single-value flattening that ParseParams applies to form
+// bodies: keys with a single value are set to that value rather than
+// a one-element []string.  Keys are used exactly as they are; see
+// ParseQueryExpanded for nested objects.  It is meant for GET
+// endpoints that never call ParseBody; the result can be passed
+// straight to UnmarshalParams, which converts the string values to
+// the types of the target's fields.
+func ParseQuery(u *url.URL) objx.Map {
+	params := make(objx.Map)
+	for key, values := range u.Query() {
+		if len(values) == 1 {
+			params[key] = values[0]
+		} else {
+			params[key] = values
+		}
+	}
+	return params
+}
+
+// ParseQueryExpanded parses the query string of u like ParseQuery, but
+// expands dotted and bracketed keys to nested objects (as objx.Maps):
+// "address.city=P" and "address[city]=P" both set "city" in the
+// "address" object.  Bracketed numbers, as in "tags[0]", are kept as
+// keys, which Unmarshaler.NumericKeySlices can read as a slice, and
+// empty brackets, as in "tags[]", are the same as no brackets.
+//
+// Keys are expanded in sorted order, so the result doesn't depend on
+// the order of the query string.  A key that is used both for a value
+// and for an object (e.g. "a=1&a.b=2") is reported as an InvalidParam
+// error, as is a key with an empty or unclosed part.
+func ParseQueryExpanded(u *url.URL) (objx.Map, error) {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	params := make(objx.Map)
+	for _, key := range keys {
+		path, err := splitQueryKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if err := setQueryValues(params, key, path, query[key]); err != nil {
+			return nil, err
+		}
+	}
+	flattenQueryValues(params)
+	return params, nil
+}
+
+// splitQueryKey splits a query key into the keys of its path, e.g.
+// "a.b[c][0]" into "a", "b", "c", and "0".  A trailing "[]" is
+// dropped.
+func splitQueryKey(key string) ([]string, error) {
+	rest := strings.TrimSuffix(key, "[]")
+	var path []string
+	for {
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			path = append(path, rest)
+			break
+		}
+		path = append(path, rest[:end])
+		if rest[end] == '.' {
+			rest = rest[end+1:]
+			continue
+		}
+		for rest = rest[end:]; strings.HasPrefix(rest, "["); {
+			closing := strings.IndexByte(rest, ']')
+			if closing < 0 {
+				return nil, InvalidParam{Param: key, Message: fmt.Sprintf("Parameter %s has an unclosed bracket", key)}
+			}
+			path = append(path, rest[1:closing])
+			rest = rest[closing+1:]
+		}
+		if rest == "" {
+			break
+		}
+		if rest[0] != '.' {
+			return nil, InvalidParam{Param: key, Message: fmt.Sprintf("Parameter %s has text after a bracket", key)}
+		}
+		rest = rest[1:]
+	}
+	for _, part := range path {
+		if part == "" {
+			return nil, InvalidParam{Param: key, Message: fmt.Sprintf("Parameter %s has an empty part", key)}
+		}
+	}
+	return path, nil
+}
+
+// setQueryValues adds values at path in params, creating nested
+// objects as needed.  Values are kept as []string until
+// flattenQueryValues runs, so that keys that name the same path (e.g.
+// "tags" and "tags[]") add to each other.
+func setQueryValues(params objx.Map, key string, path []string, values []string) error {
+	for _, part := range path[:len(path)-1] {
+		switch existing := params[part].(type) {
+		case nil:
+			child := make(objx.Map)
+			params[part] = child
+			params = child
+		case objx.Map:
+			params = existing
+		default:
+			return queryConflict(key)
+		}
+	}
+	leaf := path[len(path)-1]
+	switch existing := params[leaf].(type) {
+	case nil:
+		params[leaf] = append([]string(nil), values...)
+	case []string:
+		params[leaf] = append(existing, values...)
+	default:
+		return queryConflict(key)
+	}
+	return nil
+}
+
+// queryConflict returns the error for a query key that is used both
+// for a value and for an object.
+func queryConflict(key string) error {
+	return InvalidParam{Param: key, Message: fmt.Sprintf("Parameter %s conflicts with another parameter", key)}
+}
+
+// flattenQueryValues replaces the one-element []string values in
+// params, and in its nested objects, with their only value.
+func flattenQueryValues(params objx.Map) {
+	for key, value := range params {
+		switch src := value.(type) {
+		case objx.Map:
+			flattenQueryValues(src)
+		case []string:
+			if len(src) == 1 {
+				params[key] = src[0]
+			}
+		}
+	}
+}
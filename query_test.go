@@ -0,0 +1,69 @@
+package web_request_readers
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/objx"
+)
+
+func TestParseQueryKeepsKeys(t *testing.T) {
+	u, _ := url.Parse("/?a=1&address.city=P&tags[0]=x&tags=a&tags=b")
+	want := objx.Map{"a": "1", "address.city": "P", "tags[0]": "x", "tags": []string{"a", "b"}}
+	if params := ParseQuery(u); !reflect.DeepEqual(params, want) {
+		t.Errorf("got %#v, want %#v", params, want)
+	}
+}
+
+func TestParseQueryExpanded(t *testing.T) {
+	u, _ := url.Parse("/?a=1&address.city=P&address[zip]=9&tags[]=x&tags=y&matrix[0][1]=z&user.name.first=B")
+	params, err := ParseQueryExpanded(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := objx.Map{
+		"a":       "1",
+		"address": objx.Map{"city": "P", "zip": "9"},
+		"tags":    []string{"y", "x"},
+		"matrix":  objx.Map{"0": objx.Map{"1": "z"}},
+		"user":    objx.Map{"name": objx.Map{"first": "B"}},
+	}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("got %#v, want %#v", params, want)
+	}
+
+	var target struct {
+		Address struct {
+			City string `request:"city"`
+			Zip  int    `request:"zip"`
+		} `request:"address"`
+	}
+	u, _ = url.Parse("/?address.city=P&address[zip]=9")
+	params, _ = ParseQueryExpanded(u)
+	if err := UnmarshalParams(params, &target); err != nil || target.Address.City != "P" || target.Address.Zip != 9 {
+		t.Errorf("got %+v, %v", target, err)
+	}
+}
+
+func TestParseQueryExpandedRejectsConflicts(t *testing.T) {
+	for _, query := range []string{
+		"a=1&a.b=2",
+		"a.b=2&a=1",
+		"a[b]=1&a[b][c]=2",
+		"a[b=1",
+		"a[b]c=1",
+		"a..b=1",
+		"a.=1",
+	} {
+		u, _ := url.Parse("/?" + query)
+		var invalid InvalidParam
+		for i := 0; i < 10; i++ {
+			if params, err := ParseQueryExpanded(u); !errors.As(err, &invalid) {
+				t.Errorf("%s: expected an InvalidParam, got %v, %v", query, params, err)
+				break
+			}
+		}
+	}
+}
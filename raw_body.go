@@ -0,0 +1,66 @@
+package web_request_readers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/stretchr/goweb/context"
+)
+
+const rawBodyDataKey = "raw_body"
+
+// ErrBodyConsumed is returned by RawBody when the request body was
+// parsed before RawBody was called, without Options.KeepRawBody, so
+// its bytes are gone.
+var ErrBodyConsumed = errors.New("Request body has already been read")
+
+// RawBody returns the bytes of a request body, for code that needs
+// them as they were sent, e.g. signature verification or audit
+// logging.  The body is read (up to Options.MaxBodySize) the first
+// time RawBody is called, and request.Body is replaced with a reader
+// over the same bytes, so ParseBody and other later readers still see
+// the whole body.
+//
+// RawBody must be called before the body is parsed, unless the
+// request's Options have KeepRawBody set, in which case ParseBody
+// keeps the bytes for it.  Otherwise, it returns ErrBodyConsumed.
+func RawBody(ctx context.Context) ([]byte, error) {
+	if raw, ok := ctx.Data()[rawBodyDataKey].([]byte); ok {
+		return raw, nil
+	}
+	if _, parsed := CachedParams(ctx); parsed {
+		return nil, ErrBodyConsumed
+	}
+	opts, _ := RequestOptions(ctx)
+	return readRawBody(ctx, opts)
+}
+
+// readRawBody reads the whole request body into ctx.Data() and
+// replaces request.Body with a reader over it.
+func readRawBody(ctx context.Context, opts Options) ([]byte, error) {
+	request := ctx.HttpRequest()
+	if request.Body == nil || request.Body == http.NoBody {
+		ctx.Data()[rawBodyDataKey] = []byte{}
+		return []byte{}, nil
+	}
+	body := request.Body
+	if opts.MaxBodySize > 0 {
+		body = http.MaxBytesReader(ctx.HttpResponseWriter(), body, opts.MaxBodySize)
+	}
+	raw, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return nil, err
+	}
+	ctx.Data()[rawBodyDataKey] = raw
+	restoreRawBody(request, raw)
+	return raw, nil
+}
+
+// restoreRawBody replaces request.Body with a reader over raw, so
+// that it can be read again.
+func restoreRawBody(request *http.Request, raw []byte) {
+	request.Body = io.NopCloser(bytes.NewReader(raw))
+}
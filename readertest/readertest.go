@@ -0,0 +1,125 @@
+// Package readertest builds fake requests and contexts for testing
+// code that reads requests with web_request_readers, so that a
+// handler's unmarshalling can be unit tested without running goweb:
+//
+//	func TestCreateUser(t *testing.T) {
+//		ctx := readertest.NewContext(readertest.JSONRequest(t, "POST", "/users", map[string]interface{}{
+//			"name": "bob",
+//		}))
+//		params, err := web_request_readers.ParseParams(ctx)
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		var user User
+//		if err := web_request_readers.UnmarshalRequestParams(ctx, params, &user); err != nil {
+//			t.Fatal(err)
+//		}
+//	}
+package readertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/goweb/context"
+	"github.com/stretchr/objx"
+)
+
+// JSONRequest returns a request with body encoded as JSON, and a
+// Content-Type of application/json.  A string or []byte body is sent
+// as it is, so that malformed JSON can be tested.  t fails the test
+// if body can't be encoded.
+func JSONRequest(t testing.TB, method, path string, body interface{}) *http.Request {
+	t.Helper()
+	var encoded []byte
+	switch src := body.(type) {
+	case string:
+		encoded = []byte(src)
+	case []byte:
+		encoded = src
+	default:
+		var err error
+		if encoded, err = json.Marshal(body); err != nil {
+			t.Fatalf("readertest: encoding JSON body: %s", err)
+		}
+	}
+	request := httptest.NewRequest(method, path, bytes.NewReader(encoded))
+	request.Header.Set("Content-Type", "application/json")
+	return request
+}
+
+// FormRequest returns a request with values URL-encoded in its body,
+// and a Content-Type of application/x-www-form-urlencoded.  For GET
+// and HEAD requests, which have no body, values are added to the
+// query string instead.
+func FormRequest(t testing.TB, method, path string, values url.Values) *http.Request {
+	t.Helper()
+	if method == "GET" || method == "HEAD" {
+		target, err := url.Parse(path)
+		if err != nil {
+			t.Fatalf("readertest: parsing path: %s", err)
+		}
+		query := target.Query()
+		for key, vals := range values {
+			query[key] = append(query[key], vals...)
+		}
+		target.RawQuery = query.Encode()
+		return httptest.NewRequest(method, target.String(), nil)
+	}
+	request := httptest.NewRequest(method, path, strings.NewReader(values.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return request
+}
+
+// A Context is an in-memory context.Context for a single request,
+// with its response recorded in Recorder.  It implements the methods
+// that web_request_readers uses (Data, HttpRequest,
+// HttpResponseWriter, and MethodString); calling any other method of
+// context.Context panics.
+type Context struct {
+	context.Context
+
+	// Request is the request that the context is for.
+	Request *http.Request
+
+	// Recorder records whatever is written to the response.
+	Recorder *httptest.ResponseRecorder
+
+	data objx.Map
+}
+
+// NewContext returns a Context for request, with empty data and a
+// fresh Recorder.
+func NewContext(request *http.Request) *Context {
+	return &Context{
+		Request:  request,
+		Recorder: httptest.NewRecorder(),
+		data:     make(objx.Map),
+	}
+}
+
+// Data returns the context's data, which web_request_readers uses to
+// cache the parsed body.
+func (ctx *Context) Data() objx.Map {
+	return ctx.data
+}
+
+// HttpRequest returns ctx.Request.
+func (ctx *Context) HttpRequest() *http.Request {
+	return ctx.Request
+}
+
+// HttpResponseWriter returns ctx.Recorder.
+func (ctx *Context) HttpResponseWriter() http.ResponseWriter {
+	return ctx.Recorder
+}
+
+// MethodString returns the request's method.
+func (ctx *Context) MethodString() string {
+	return ctx.Request.Method
+}
@@ -0,0 +1,83 @@
+package web_request_readers
+
+import (
+	"errors"
+)
+
+// A ClientError is an error that knows whether or not it was caused by
+// the request, as opposed to a failure on the server's side.
+// Receivers can return errors that implement it (e.g. with
+// NewClientError) so that callers can tell bad input, which should get
+// a 4xx response, from internal errors, such as a failed database
+// lookup, which should get a 5xx response.
+type ClientError interface {
+	error
+	ClientError() bool
+}
+
+// clientError is the ClientError returned by NewClientError.
+type clientError struct {
+	message string
+}
+
+func (err clientError) Error() string {
+	return err.message
+}
+
+func (err clientError) ClientError() bool {
+	return true
+}
+
+// NewClientError returns an error with the given message that reports
+// itself as a client error.
+func NewClientError(message string) error {
+	return clientError{message: message}
+}
+
+// A ReceiverError wraps an error returned by one of a field's
+// receiver methods (Receive, ReceiveNamed, ReceiveContext, or any of
+// the pre- and post-receive methods), naming the field that failed.
+// Receiver errors are still reported as FieldErrors, so use
+// errors.As to find the ReceiverError.
+type ReceiverError struct {
+	// Field is the request key of the field, and FieldPath is the
+	// dotted path of struct field names leading to it.
+	Field     string
+	FieldPath string
+
+	// Err is the error the receiver returned.
+	Err error
+}
+
+// Error returns the error message for a ReceiverError, which is the
+// receiver's own message.
+func (err ReceiverError) Error() string {
+	return err.Err.Error()
+}
+
+// Unwrap returns the receiver's error.
+func (err ReceiverError) Unwrap() error {
+	return err.Err
+}
+
+// IsClientError returns whether or not the receiver's error was caused
+// by the request.  Only errors that implement ClientError (anywhere in
+// their chain) can be client errors; any other error is assumed to be
+// internal.
+func (err ReceiverError) IsClientError() bool {
+	var client ClientError
+	return errors.As(err.Err, &client) && client.ClientError()
+}
+
+// receiverError wraps an error from a receiver method of the field
+// currently being set.
+func (state *unmarshalState) receiverError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return ReceiverError{
+		Field:     state.keyPath + state.fieldName,
+		FieldPath: state.fieldPath + state.goFieldName,
+		Err:       err,
+	}
+}
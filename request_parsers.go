@@ -1,23 +1,32 @@
 package web_request_readers
 
 import (
+	"bytes"
 	"encoding/json"
 	codec_services "github.com/stretchr/codecs/services"
 	"github.com/stretchr/goweb/context"
 	"github.com/stretchr/objx"
 	"io/ioutil"
+	"log/slog"
+	"net/http"
 	"strconv"
-	"errors"
+	"fmt"
+	"time"
 )
 
-var multipartMem int64 = 2 << 20 * 10
+// multipartMem is the initial value of Config.MultipartMem.
+const multipartMem int64 = 2 << 20 * 10
 
+// MultipartMem returns the maximum number of bytes of a multipart
+// body that will be held in memory.
 func MultipartMem() int64 {
-	return multipartMem
+	return CurrentConfig().MultipartMem
 }
 
+// SetMultipartMem atomically changes MultipartMem.  It is safe to
+// call while requests are being handled.
 func SetMultipartMem(mem int64) {
-	multipartMem = mem
+	updateConfig(func(c *Config) { c.MultipartMem = mem })
 }
 
 // ConvertMSIToObjxMap recursively converts map[string]interface{}
@@ -42,7 +51,8 @@ func ConvertMSIToObjxMap(value interface{}) interface{} {
 // ParseParams will parse parameters out of a request body.  The
 // result will be an objx.Map of values, or an error if something
 // unexpected happened.  All map[string]interface{} values are
-// converted to objx.Map before returning.
+// converted to objx.Map before returning.  If the body isn't an
+// object, the error is of type WrongBodyShape.
 func ParseParams(ctx context.Context) (objx.Map, error) {
 	val, err := ParseBody(ctx)
 	if err != nil {
@@ -53,7 +63,7 @@ func ParseParams(ctx context.Context) (objx.Map, error) {
 	}
 	params, ok := val.(objx.Map)
 	if !ok {
-		return nil, errors.New("Cannot use non-map body as params")
+		return nil, WrongBodyShape{Kind: bodyKind(val), Value: val}
 	}
 	return params, nil
 }
@@ -61,103 +71,279 @@ func ParseParams(ctx context.Context) (objx.Map, error) {
 // ParseBody will parse a request body, regardless of type.  The body
 // could be a json array, and this will return it properly.  All
 // map[string]interface{} values are converted to objx.Map before
-// returning.
+// returning.  A description of the body's content type and the
+// decoder that read it is available from ParsedContentOf afterwards.
+//
+// The result is cached in ctx.Data() under the key set with
+// SetParamsDataKey (ParamsDataKey by default), until
+// InvalidateParsedBody is called.  If Options.HoneypotFields are set
+// and any of them has a value, the error is a SpamDetected, on every
+// call.
 func ParseBody(ctx context.Context) (interface{}, error) {
-	if params, ok := ctx.Data()["params"]; ok {
+	// The whole parse uses one snapshot of the configuration.
+	current := CurrentConfig()
+	opts, _ := RequestOptions(ctx)
+	if params, ok := ctx.Data()[current.paramsDataKey()]; ok {
 		// We've already parsed this request, so return the cached
 		// parameters.
+		if paramsMap, ok := params.(objx.Map); ok && len(opts.HoneypotFields) > 0 {
+			if err := checkHoneypots(paramsMap, opts.HoneypotFields); err != nil {
+				return nil, err
+			}
+		}
 		return params, nil
 	}
-	request := ctx.HttpRequest()
-	var response interface{}
-	contentType, _ := codec_services.ParseContentType(request.Header.Get("Content-Type"))
-	var mimeType string
-	if contentType != nil {
-		mimeType = contentType.MimeType
+	response, err := decodeRequestBody(ctx, current, opts, func(request *http.Request, content *ParsedContent) (interface{}, error) {
+		return parseBody(request, content, current, true)
+	})
+	if err != nil {
+		return nil, err
 	}
-	switch mimeType {
-	case "text/json":
-		fallthrough
-	case "application/json":
-		body, err := ioutil.ReadAll(request.Body)
+	if params, ok := response.(objx.Map); ok && current.InjectUserAgent {
+		injectUserAgent(ctx, params)
+	}
+	ctx.Data()[current.paramsDataKey()] = response
+	if params, ok := response.(objx.Map); ok && len(opts.HoneypotFields) > 0 {
+		if err := checkHoneypots(params, opts.HoneypotFields); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// ReadJSONBody decodes a request body as JSON, whatever its
+// Content-Type, for parsers of JSON-based media types that ParseBody
+// doesn't handle itself (like the jsonapi sub-package's ParseParams).
+// The body is read the same way ParseBody reads it: with the request's
+// Options.MaxBodySize and KeepRawBody, the JSONNumbers and
+// DuplicateKeys settings, and the body hooks and tracer, and its
+// ParsedContent is recorded for ParsedContentOf.  Unlike ParseBody,
+// the result is not cached; callers that convert it to params can
+// cache those with CacheParams.
+func ReadJSONBody(ctx context.Context) (interface{}, error) {
+	current := CurrentConfig()
+	opts, _ := RequestOptions(ctx)
+	return decodeRequestBody(ctx, current, opts, func(request *http.Request, content *ParsedContent) (interface{}, error) {
+		response, err := parseJSONBody(request, content, current)
 		if err != nil {
 			return nil, err
 		}
-		if err = json.Unmarshal(body, &response); err != nil {
+		return ConvertMSIToObjxMap(response), nil
+	})
+}
+
+// decodeRequestBody reads ctx's request body with readBody, keeping
+// the raw body first if opts.KeepRawBody is set, and records the
+// body's ParsedContent.
+func decodeRequestBody(ctx context.Context, current Config, opts Options, parse bodyParser) (interface{}, error) {
+	if opts.KeepRawBody {
+		if _, err := RawBody(ctx); err != nil {
+			return nil, err
+		}
+	}
+	response, content, err := readBody(ctx.HttpRequest(), ctx.HttpResponseWriter(), current, opts, parse)
+	if raw, ok := ctx.Data()[rawBodyDataKey].([]byte); ok {
+		// Let later readers see the body too.
+		restoreRawBody(ctx.HttpRequest(), raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ctx.Data().Set(parsedContentDataKey, content)
+	return response, nil
+}
+
+// bodyParser decodes a request body, filling in content as it goes.
+type bodyParser func(request *http.Request, content *ParsedContent) (interface{}, error)
+
+// readBody decodes a request body with parse, running the body hooks
+// and the tracer around the decoding, with the settings of current.
+func readBody(request *http.Request, w http.ResponseWriter, current Config, opts Options, parse bodyParser) (interface{}, ParsedContent, error) {
+	if opts.MaxBodySize > 0 {
+		request.Body = http.MaxBytesReader(w, request.Body, opts.MaxBodySize)
+	}
+	var content ParsedContent
+	contentType, _ := codec_services.ParseContentType(request.Header.Get("Content-Type"))
+	if contentType != nil {
+		content.MimeType = contentType.MimeType
+		content.Charset = contentType.Parameters["charset"]
+		content.Boundary = contentType.Parameters["boundary"]
+	}
+	for _, hook := range bodyParseHooks {
+		hook(content.MimeType)
+	}
+	tracer := current.Tracer
+	var finishTrace func(int, error)
+	if tracer != nil {
+		finishTrace = tracer.StartParseBody(request.Context(), content.MimeType)
+	}
+	var counter *countingReader
+	if (len(bodyParsedHooks) > 0 || tracer != nil || opts.Logger != nil) && request.Body != nil {
+		counter = &countingReader{ReadCloser: request.Body}
+		request.Body = counter
+	}
+	start := time.Now()
+	response, err := parse(request, &content)
+	duration := time.Since(start)
+	var size int
+	if counter != nil {
+		size = counter.count
+	}
+	for _, hook := range bodyParsedHooks {
+		hook(content.MimeType, size, duration, err)
+	}
+	if finishTrace != nil {
+		finishTrace(size, err)
+	}
+	if opts.Logger != nil {
+		opts.Logger.LogAttrs(request.Context(), slog.LevelDebug, "parsed request body",
+			slog.String("mime", content.MimeType),
+			slog.String("decoder", content.Decoder),
+			slog.Int("size", size),
+			slog.Duration("duration", duration),
+			errorAttr(err))
+	}
+	return response, content, err
+}
+
+// parseBody decodes a request body according to content.MimeType,
+// filling in the rest of content as it goes.
+func parseBody(request *http.Request, content *ParsedContent, current Config, withQuery bool) (interface{}, error) {
+	var response interface{}
+	mimeType := content.MimeType
+	switch mimeType {
+	case "text/json", "application/json", MergePatchType, JSONPatchType:
+		var err error
+		if response, err = parseJSONBody(request, content, current); err != nil {
 			return nil, err
 		}
 	default:
+		if mimeType != "" && current.StrictContentTypes {
+			return nil, UnsupportedMediaType{MimeType: mimeType, Supported: SupportedMediaTypes}
+		}
 		fallthrough
 	case "application/x-www-form-urlencoded":
 		fallthrough
 	case "multipart/form-data":
 		params := make(objx.Map)
-		request.ParseMultipartForm(MultipartMem())
+		request.ParseMultipartForm(current.MultipartMem)
+		content.Decoder = FormDecoder
 		if request.MultipartForm != nil {
-			params.Set("files", request.MultipartForm.File)
-			for key, values := range request.MultipartForm.Value {
-				if len(values) == 1 {
-					params.Set(key, values[0])
-				} else {
-					params.Set(key, values)
-				}
+			content.Decoder = MultipartDecoder
+			if err := ValidateFileTypes(request.MultipartForm.File); err != nil {
+				return nil, err
 			}
+			params.Set("files", request.MultipartForm.File)
+			setFormValues(params, request.MultipartForm.Value)
 		}
-		for index, values := range request.Form {
-			if len(values) == 1 {
-				// Okay, so, here's how this works.  I hate just
-				// assuming that there's only one value when I'm
-				// reading a form, so I always end up testing the
-				// length, which adds boilerplate code.  I want my
-				// param parser to handle that case, so instead of
-				// always adding a slice of values, I'm only adding
-				// the single value if the length of the slice is 1.
-				params.Set(index, values[0])
-			} else {
-				params.Set(index, values)
-			}
+		if withQuery {
+			setFormValues(params, request.Form)
+		} else {
+			setFormValues(params, request.PostForm)
 		}
 		response = params
 	}
-	response = ConvertMSIToObjxMap(response)
-	ctx.Data().Set("params", response)
-	return response, nil
+	return ConvertMSIToObjxMap(response), nil
 }
 
-// ParsePage reads "page" and "page_size" from a set of parameters and
-// parses them into offset and limit values.
-//
-// It assumes that the params will be coming from a set of query
-// parameters, and that the page and page_size values will be of type
-// []string, since that's the default for query parameters.  It will
-// simply read the first value from each slice, ignoring extra values.
-func ParsePage(params objx.Map, defaultPageSize int) (offset, limit int, err error) {
-	limit = defaultPageSize
+// parseJSONBody decodes a JSON request body, checking it for
+// duplicate keys according to current.DuplicateKeys.
+func parseJSONBody(request *http.Request, content *ParsedContent, current Config) (interface{}, error) {
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	var response interface{}
+	if err = decodeJSON(body, &response, current.JSONNumbers); err != nil {
+		return nil, err
+	}
+	if policy := current.DuplicateKeys; policy != DuplicateKeysIgnore {
+		if duplicates := duplicateJSONKeys(body); len(duplicates) > 0 {
+			if policy == DuplicateKeysReject {
+				return nil, DuplicateKeys{Names: duplicates}
+			}
+			content.DuplicateKeys = duplicates
+		}
+	}
+	switch content.MimeType {
+	case MergePatchType:
+		content.Decoder = MergePatchDecoder
+	case JSONPatchType:
+		content.Decoder = JSONPatchDecoder
+	default:
+		content.Decoder = JSONDecoder
+	}
+	return response, nil
+}
 
-	sizeVal, sizeOk := params["page_size"]
-	pageVal, pageOk := params["page"]
+// decodeJSON decodes a JSON body, keeping numbers as json.Number if
+// numbers is set (see Config.JSONNumbers).
+func decodeJSON(body []byte, response *interface{}, numbers bool) error {
+	if !numbers || !json.Valid(body) {
+		// json.Unmarshal gives the same errors either way.
+		return json.Unmarshal(body, response)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	return decoder.Decode(response)
+}
 
-	if sizeOk {
-		sizeSlice := sizeVal.([]string)
-		sizeStr := sizeSlice[0]
-		var pageSize int
-		pageSize, err = strconv.Atoi(sizeStr)
-		if err != nil {
-			return
+// setFormValues copies form values to params.
+func setFormValues(params objx.Map, values map[string][]string) {
+	for key, vals := range values {
+		if len(vals) == 1 {
+			// Okay, so, here's how this works.  I hate just
+			// assuming that there's only one value when I'm
+			// reading a form, so I always end up testing the
+			// length, which adds boilerplate code.  I want my
+			// param parser to handle that case, so instead of
+			// always adding a slice of values, I'm only adding
+			// the single value if the length of the slice is 1.
+			params.Set(key, vals[0])
+		} else {
+			params.Set(key, vals)
 		}
-		limit = pageSize
 	}
+}
 
-	if pageOk {
-		pageSlice := pageVal.([]string)
-		pageStr := pageSlice[0]
-		var page int
-		page, err = strconv.Atoi(pageStr)
-		if err != nil {
-			return
-		}
-		offset = (page - 1) * limit
+// ParsePage reads "page" and "page_size" from a set of parameters and
+// parses them into offset and limit values.  It is a shortcut for
+// ParsePagination, for callers that only need the offset and limit.
+func ParsePage(params objx.Map, defaultPageSize int) (offset, limit int, err error) {
+	page, err := ParsePagination(params, defaultPageSize)
+	if err != nil {
+		return 0, 0, err
 	}
+	return page.Offset, page.Limit, nil
+}
 
-	return
+// intParam reads an int from a param value, which may be a string, a
+// []string, or a number.  Errors are of type InvalidParam.
+func intParam(name string, value interface{}) (int, error) {
+	var text string
+	switch src := value.(type) {
+	case string:
+		text = src
+	case []string:
+		if len(src) == 0 {
+			return 0, InvalidParam{Param: name, Message: fmt.Sprintf("No value for parameter %s", name)}
+		}
+		text = src[0]
+	case json.Number:
+		text = string(src)
+	case int:
+		return src, nil
+	case float64:
+		if src != float64(int(src)) || src >= 0x1p63 || src < -0x1p63 {
+			return 0, InvalidParam{Param: name, Message: fmt.Sprintf("Parameter %s must be a whole number", name)}
+		}
+		return int(src), nil
+	default:
+		return 0, InvalidParam{Param: name, Message: fmt.Sprintf("Cannot read parameter %s from value of type %T", name, value)}
+	}
+	parsed, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, InvalidParam{Param: name, Message: fmt.Sprintf("Parameter %s must be a whole number", name), Err: err}
+	}
+	return parsed, nil
 }
@@ -0,0 +1,168 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/stretchr/objx"
+)
+
+// Request sections, named by the tag options of the fields that
+// BindRequest fills in.
+const (
+	BodySection   = "body"
+	QuerySection  = "query"
+	HeaderSection = "header"
+)
+
+// BindRequest fills in the sections of target from r, so that an
+// endpoint can be described by one struct:
+//
+//	type UpdateUser struct {
+//		Body struct {
+//			Name string `request:"name"`
+//		} `request:",body"`
+//		Query struct {
+//			Notify bool `request:"notify,optional"`
+//		} `request:",query"`
+//		Header struct {
+//			IfMatch string `request:"if-match,optional"`
+//		} `request:",header"`
+//	}
+//
+// Each section is a struct (or a pointer to one) tagged with the
+// "body", "query", or "header" option, and is unmarshalled like a
+// target of its own: the body section from the request body, as
+// ParseParams would read it but without the query string; the query
+// section from the query string, as ParseQuery reads it (or as
+// ParseQueryExpanded does, if the section also has the "expand"
+// option); and the header section from the request headers, whose
+// keys are the lowercased header names.  Headers that no field asks
+// for are ignored, rather than reported as ExtraFields.  Since query
+// and header values are always strings, those sections may also read
+// numbers and bools from them.  Fields without a section option are
+// left alone.
+//
+// Sections are filled in struct order, and the first error is
+// returned as the section returned it, so the usual type tests (e.g.
+// for MissingFields) still work.  The body is only read if target has
+// a body section.  Options attached to r's context with WithOptions
+// apply, and r's context is passed to the unmarshal as with
+// UnmarshalParamsCtx.
+func BindRequest(r *http.Request, target interface{}) error {
+	return DefaultUnmarshaler.BindRequest(r, target)
+}
+
+// BindRequest fills in the sections of target from r using the
+// unmarshaler's options.  See the package-level BindRequest for
+// details.
+func (unmarshaler *Unmarshaler) BindRequest(r *http.Request, target interface{}) error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() || targetValue.Elem().Kind() != reflect.Struct {
+		return invalidTarget("BindRequest", "a non-nil pointer to a struct", target)
+	}
+	opts, _ := r.Context().Value(optionsKey{}).(Options)
+	tagName := opts.TagName
+	if tagName == "" {
+		tagName = DefaultTagName
+	}
+
+	structValue := targetValue.Elem()
+	structType := structValue.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		fieldType := structType.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+		_, args, _ := nameAndArgsFor(fieldType, tagName)
+		section := requestSection(args)
+		if section == "" {
+			continue
+		}
+		field := structValue.Field(i)
+		if field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct {
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			field = field.Elem()
+		}
+		if field.Kind() != reflect.Struct {
+			return fmt.Errorf("BindRequest section %s must be a struct, not %s", fieldType.Name, fieldType.Type)
+		}
+
+		var params objx.Map
+		var decoder string
+		switch section {
+		case BodySection:
+			current := CurrentConfig()
+			body, content, err := readBody(r, nil, current, opts, func(request *http.Request, content *ParsedContent) (interface{}, error) {
+				return parseBody(request, content, current, false)
+			})
+			if err != nil {
+				return err
+			}
+			if body != nil {
+				var ok bool
+				if params, ok = body.(objx.Map); !ok {
+					return WrongBodyShape{Kind: bodyKind(body), Value: body}
+				}
+			}
+			decoder = content.Decoder
+		case QuerySection:
+			if containsString(args, "expand") {
+				var err error
+				if params, err = ParseQueryExpanded(r.URL); err != nil {
+					return err
+				}
+			} else {
+				params = ParseQuery(r.URL)
+			}
+			decoder = FormDecoder
+		case HeaderSection:
+			params, decoder = headerParams(r.Header), FormDecoder
+		}
+		state := unmarshaler.newState(nil, params)
+		state.goCtx = r.Context()
+		state.request = r
+		state.decoder = decoder
+		state.stringValues = section != BodySection
+		// Clients and proxies add headers of their own.
+		state.ignoreExtra = section == HeaderSection
+		err := unmarshaler.unmarshal(state, field.Addr().Interface())
+		state.release()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requestSection returns the section named by a field's tag options,
+// or "" if it isn't a section.
+func requestSection(args []string) string {
+	for _, arg := range args {
+		switch arg {
+		case BodySection, QuerySection, HeaderSection:
+			return arg
+		}
+	}
+	return ""
+}
+
+// headerParams converts request headers to params, keyed by their
+// lowercased names.  Headers with a single value are set to that
+// value, like form values.
+func headerParams(header http.Header) objx.Map {
+	params := make(objx.Map, len(header))
+	for name, values := range header {
+		key := strings.ToLower(name)
+		if len(values) == 1 {
+			params[key] = values[0]
+		} else {
+			params[key] = values
+		}
+	}
+	return params
+}
@@ -0,0 +1,43 @@
+package web_request_readers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+type testListRequest struct {
+	Query struct {
+		Limit  int  `request:"limit"`
+		Active bool `request:"active,optional"`
+	} `request:",query"`
+}
+
+func TestBindRequestQueryWithStrictness(t *testing.T) {
+	for _, strictness := range []Strictness{Lenient, Strict} {
+		unmarshaler := &Unmarshaler{Strictness: strictness}
+		var target testListRequest
+		if err := unmarshaler.BindRequest(httptest.NewRequest("GET", "/?limit=5&active=true", nil), &target); err != nil {
+			t.Errorf("strictness %v: %v", strictness, err)
+			continue
+		}
+		if target.Query.Limit != 5 || !target.Query.Active {
+			t.Errorf("strictness %v: unexpected query %+v", strictness, target.Query)
+		}
+	}
+}
+
+func TestBindRequestExpandedQuery(t *testing.T) {
+	var target struct {
+		Query struct {
+			Filter struct {
+				Status string `request:"status"`
+			} `request:"filter"`
+		} `request:",query,expand"`
+	}
+	if err := BindRequest(httptest.NewRequest("GET", "/?filter[status]=open", nil), &target); err != nil || target.Query.Filter.Status != "open" {
+		t.Errorf("got %+v, %v", target.Query, err)
+	}
+	if err := BindRequest(httptest.NewRequest("GET", "/?filter=open&filter.status=open", nil), &target); err == nil {
+		t.Error("accepted a conflicting query")
+	}
+}
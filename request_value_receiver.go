@@ -1,5 +1,11 @@
 package web_request_readers
 
+import (
+	"context"
+
+	"github.com/stretchr/objx"
+)
+
 // A RequestValueReceiver is a type that receives a value from a
 // request and performs its own logic to parse that value to a value
 // of its own type.
@@ -19,6 +25,16 @@ type RequestValueReceiver interface {
 	Receive(interface{}) error
 }
 
+// A NamedReceiver is like a RequestValueReceiver, but it is also told
+// the request key that the value came from and given the full set of
+// request params.  This allows for cross-field logic (e.g. hashing a
+// password with a salt from another field) and for error messages
+// that name the field.  If a type implements both interfaces, only
+// ReceiveNamed is called.
+type NamedReceiver interface {
+	ReceiveNamed(name string, value interface{}, params objx.Map) error
+}
+
 // A PreReceiver has an action to perform prior to receiving data from
 // a user request.
 type PreReceiver interface {
@@ -30,3 +46,40 @@ type PreReceiver interface {
 type PostReceiver interface {
 	PostReceive() error
 }
+
+// A ValuePreReceiver is a PreReceiver that is given the incoming
+// request value, e.g. to validate it before it is assigned.  If a type
+// implements both, only PreReceiveValue is called.
+type ValuePreReceiver interface {
+	PreReceiveValue(value interface{}) error
+}
+
+// A ValuePostReceiver is a PostReceiver that is given the request
+// value that was just received.  If a type implements both, only
+// PostReceiveValue is called.
+type ValuePostReceiver interface {
+	PostReceiveValue(value interface{}) error
+}
+
+// A ContextReceiver is a RequestValueReceiver that needs the
+// context.Context passed to UnmarshalParamsCtx, e.g. to honor request
+// deadlines during a database lookup.  It takes priority over
+// NamedReceiver and RequestValueReceiver.  When unmarshalling without
+// a context, context.Background() is passed.
+type ContextReceiver interface {
+	ReceiveContext(ctx context.Context, value interface{}) error
+}
+
+// A ContextPreReceiver is a PreReceiver that needs the
+// context.Context passed to UnmarshalParamsCtx.  It takes priority
+// over ValuePreReceiver and PreReceiver.
+type ContextPreReceiver interface {
+	PreReceiveContext(ctx context.Context) error
+}
+
+// A ContextPostReceiver is a PostReceiver that needs the
+// context.Context passed to UnmarshalParamsCtx.  It takes priority
+// over ValuePostReceiver and PostReceiver.
+type ContextPostReceiver interface {
+	PostReceiveContext(ctx context.Context) error
+}
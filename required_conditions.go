@@ -0,0 +1,43 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// requiredByCondition returns whether a field is required because of
+// the other values in the request, using its conditional requiredness
+// tag options:
+//
+//	required_if=type:business   required when the request's type is
+//	                            "business"; several values may be
+//	                            given, e.g. type:business|nonprofit
+//	required_with=password      required when the request has a
+//	                            password; several keys may be given,
+//	                            e.g. password|password_confirm
+//
+// Both options may be repeated, and the field is required if any of
+// them apply.  The keys they name are request keys in the same object
+// as the field (after any prefix is applied), and values are compared
+// using their string form.  Fields that are required this way are
+// reported in MissingFields like any other required field.
+func (state *unmarshalState) requiredByCondition(args []string) bool {
+	for _, condition := range tagOptions(args, "required_if") {
+		key, values, ok := strings.Cut(condition, ":")
+		if !ok {
+			continue
+		}
+		value, present := state.lookup([]string{state.prefix + key}, false)
+		if present && value != nil && containsString(strings.Split(values, "|"), fmt.Sprint(value)) {
+			return true
+		}
+	}
+	for _, keys := range tagOptions(args, "required_with") {
+		for _, key := range strings.Split(keys, "|") {
+			if value, present := state.lookup([]string{state.prefix + key}, false); present && value != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package web_request_readers
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// A ResponseError describes a single problem with a request in an
+// ErrorResponse.
+type ResponseError struct {
+	// Field is the request key of the field, using the same dotted
+	// paths as the error it came from.
+	Field string `json:"field"`
+
+	// Code is a short, machine-readable description of the
+	// problem: "missing", "unknown", "forbidden", or the Code of a
+	// FieldError.
+	Code string `json:"code"`
+
+	// Message is the human-readable error message.
+	Message string `json:"message"`
+}
+
+// An ErrorResponse is the machine-readable form of the errors that
+// UnmarshalParams returns for bad requests.  It marshals to
+//
+//	{"errors": [{"field": "...", "code": "...", "message": "..."}]}
+//
+// and is what MissingFields, ExtraFields, ForbiddenFields, FieldError,
+// and FieldErrors marshal to, so handlers can pass any of those
+// straight to their codec.
+type ErrorResponse struct {
+	Errors []ResponseError `json:"errors"`
+}
+
+// responder is implemented by the error types that can be converted
+// to an ErrorResponse.
+type responder interface {
+	ToResponse() ErrorResponse
+}
+
+// ResponseFor returns the ErrorResponse for err, if err is (or wraps)
+// one of the error types that has one.
+func ResponseFor(err error) (ErrorResponse, bool) {
+	var target responder
+	if errors.As(err, &target) {
+		return target.ToResponse(), true
+	}
+	return ErrorResponse{}, false
+}
+
+// ToResponse returns the ErrorResponse for a MissingFields error, with
+// the code "missing" for each field.
+func (err MissingFields) ToResponse() ErrorResponse {
+	entries := err.Entries()
+	response := ErrorResponse{Errors: make([]ResponseError, len(entries))}
+	for index, entry := range entries {
+		response.Errors[index] = ResponseError{
+			Field:   entry.RequestKey,
+			Code:    "missing",
+			Message: "Missing value for field " + entry.RequestKey,
+		}
+	}
+	return response
+}
+
+// MarshalJSON encodes a MissingFields error as an ErrorResponse.
+func (err MissingFields) MarshalJSON() ([]byte, error) {
+	return json.Marshal(err.ToResponse())
+}
+
+// ToResponse returns the ErrorResponse for an ExtraFields error, with
+// the code "unknown" for each key.
+func (err ExtraFields) ToResponse() ErrorResponse {
+	response := ErrorResponse{Errors: make([]ResponseError, len(err.Names))}
+	for index, name := range err.Names {
+		response.Errors[index] = ResponseError{
+			Field:   name,
+			Code:    "unknown",
+			Message: "Unknown field " + name,
+		}
+	}
+	return response
+}
+
+// MarshalJSON encodes an ExtraFields error as an ErrorResponse.
+func (err ExtraFields) MarshalJSON() ([]byte, error) {
+	return json.Marshal(err.ToResponse())
+}
+
+// ToResponse returns the ErrorResponse for a ForbiddenFields error,
+// with the code "forbidden" for each field.
+func (err ForbiddenFields) ToResponse() ErrorResponse {
+	response := ErrorResponse{Errors: make([]ResponseError, len(err.Names))}
+	for index, name := range err.Names {
+		response.Errors[index] = ResponseError{
+			Field:   name,
+			Code:    "forbidden",
+			Message: "Cannot set field " + name + " from a request",
+		}
+	}
+	return response
+}
+
+// MarshalJSON encodes a ForbiddenFields error as an ErrorResponse.
+func (err ForbiddenFields) MarshalJSON() ([]byte, error) {
+	return json.Marshal(err.ToResponse())
+}
+
+// ToResponse returns the ErrorResponse for a FieldError, which has a
+// single entry.
+func (err FieldError) ToResponse() ErrorResponse {
+	return ErrorResponse{Errors: []ResponseError{err.responseError()}}
+}
+
+// MarshalJSON encodes a FieldError as an ErrorResponse.
+func (err FieldError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(err.ToResponse())
+}
+
+// ToResponse returns the ErrorResponse for a FieldErrors error, with
+// an entry for each FieldError.
+func (errs FieldErrors) ToResponse() ErrorResponse {
+	response := ErrorResponse{Errors: make([]ResponseError, len(errs))}
+	for index, err := range errs {
+		response.Errors[index] = err.responseError()
+	}
+	return response
+}
+
+// MarshalJSON encodes a FieldErrors error as an ErrorResponse.
+func (errs FieldErrors) MarshalJSON() ([]byte, error) {
+	return json.Marshal(errs.ToResponse())
+}
+
+// responseError returns the ResponseError for a single FieldError.
+func (err FieldError) responseError() ResponseError {
+	return ResponseError{Field: err.Field, Code: err.Code, Message: err.Message}
+}
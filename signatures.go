@@ -0,0 +1,195 @@
+package web_request_readers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stretchr/goweb/context"
+)
+
+// A SignatureScheme says how VerifySignature finds and checks the
+// HMAC-SHA256 signature of a request body.
+type SignatureScheme int
+
+const (
+	// SignatureHex is a hex-encoded HMAC of the body, with nothing
+	// else in the header.
+	SignatureHex SignatureScheme = iota
+
+	// SignatureBase64 is a base64-encoded HMAC of the body, in
+	// either the standard or URL-safe alphabet.
+	SignatureBase64
+
+	// SignatureGitHub is GitHub's scheme: "sha256=" and a
+	// hex-encoded HMAC of the body, in X-Hub-Signature-256 by
+	// default.
+	SignatureGitHub
+
+	// SignatureStripe is Stripe's scheme: "t=<timestamp>,v1=<hex>",
+	// where the HMAC is of the timestamp, ".", and the body, in
+	// Stripe-Signature by default.  Several v1 signatures may be
+	// sent while a secret is being rolled; any of them may match.
+	SignatureStripe
+
+	// SignatureSlack is Slack's scheme: "v0=" and a hex-encoded
+	// HMAC of "v0:<timestamp>:<body>", in X-Slack-Signature by
+	// default, with the timestamp in X-Slack-Request-Timestamp.
+	SignatureSlack
+)
+
+// DefaultSignatureTolerance is the signature tolerance used when
+// Config.SignatureTolerance is zero.
+const DefaultSignatureTolerance = 5 * time.Minute
+
+// SetSignatureTolerance atomically changes how far the timestamp of a
+// signature may be from the current time.  Zero restores
+// DefaultSignatureTolerance.  See Config.SignatureTolerance.
+func SetSignatureTolerance(tolerance time.Duration) {
+	updateConfig(func(c *Config) { c.SignatureTolerance = tolerance })
+}
+
+// signatureTolerance returns the current signature tolerance.
+func signatureTolerance() time.Duration {
+	if tolerance := CurrentConfig().SignatureTolerance; tolerance != 0 {
+		return tolerance
+	}
+	return DefaultSignatureTolerance
+}
+
+// defaultHeader returns the header that scheme's signatures are sent
+// in when VerifySignature isn't given one.
+func (scheme SignatureScheme) defaultHeader() string {
+	switch scheme {
+	case SignatureGitHub:
+		return "X-Hub-Signature-256"
+	case SignatureStripe:
+		return "Stripe-Signature"
+	case SignatureSlack:
+		return "X-Slack-Signature"
+	}
+	return ""
+}
+
+// InvalidSignature is the error returned by VerifySignature when a
+// request's signature is missing or doesn't match its body.
+type InvalidSignature struct {
+	// Header is the header the signature was read from.
+	Header string
+
+	// Reason says what was wrong, e.g. "signature does not
+	// match".
+	Reason string
+}
+
+// Error returns the error message for an InvalidSignature error.
+func (err InvalidSignature) Error() string {
+	return fmt.Sprintf("Invalid signature in header %s: %s", err.Header, err.Reason)
+}
+
+// ErrSignatureConfig is returned by VerifySignature when it is given
+// no secret, since an empty key would let anyone sign requests.
+var ErrSignatureConfig = errors.New("VerifySignature requires a secret")
+
+// VerifySignature checks that a request body was signed with secret,
+// as webhook senders do, returning an InvalidSignature error if it
+// wasn't.  The signature is read from headerName, or from the
+// scheme's usual header if headerName is empty, and compared in
+// constant time.  Schemes whose signatures have a prefix, such as
+// GitHub's "sha256=", reject signatures without it.
+//
+// The signature covers the body's bytes exactly as they were sent, so
+// VerifySignature reads them with RawBody, and must be called before
+// the body is parsed (or after, if the request's Options have
+// KeepRawBody set).  The body can still be parsed afterwards.
+func VerifySignature(ctx context.Context, secret []byte, headerName string, scheme SignatureScheme) error {
+	if len(secret) == 0 {
+		return ErrSignatureConfig
+	}
+	if headerName == "" {
+		headerName = scheme.defaultHeader()
+	}
+	header := ctx.HttpRequest().Header
+	value := strings.TrimSpace(header.Get(headerName))
+	if value == "" {
+		return InvalidSignature{Header: headerName, Reason: "signature is missing"}
+	}
+	body, err := RawBody(ctx)
+	if err != nil {
+		return err
+	}
+
+	message := body
+	signatures := []string{value}
+	decode := hex.DecodeString
+	switch scheme {
+	case SignatureBase64:
+		decode = decodeBase64
+	case SignatureGitHub:
+		var ok bool
+		if signatures[0], ok = strings.CutPrefix(value, "sha256="); !ok {
+			return InvalidSignature{Header: headerName, Reason: `signature is missing the "sha256=" prefix`}
+		}
+	case SignatureStripe:
+		var timestamp string
+		signatures = nil
+		for _, part := range strings.Split(value, ",") {
+			key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch key {
+			case "t":
+				timestamp = val
+			case "v1":
+				signatures = append(signatures, val)
+			}
+		}
+		if err := checkSignatureTime(headerName, timestamp); err != nil {
+			return err
+		}
+		message = signedMessage(timestamp+".", body)
+	case SignatureSlack:
+		timestamp := header.Get("X-Slack-Request-Timestamp")
+		if err := checkSignatureTime(headerName, timestamp); err != nil {
+			return err
+		}
+		var ok bool
+		if signatures[0], ok = strings.CutPrefix(value, "v0="); !ok {
+			return InvalidSignature{Header: headerName, Reason: `signature is missing the "v0=" prefix`}
+		}
+		message = signedMessage("v0:"+timestamp+":", body)
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(message)
+	expected := mac.Sum(nil)
+	for _, signature := range signatures {
+		if decoded, err := decode(signature); err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return InvalidSignature{Header: headerName, Reason: "signature does not match"}
+}
+
+// signedMessage returns prefix followed by body.
+func signedMessage(prefix string, body []byte) []byte {
+	message := make([]byte, 0, len(prefix)+len(body))
+	return append(append(message, prefix...), body...)
+}
+
+// checkSignatureTime returns an InvalidSignature error unless
+// timestamp is a Unix time within the signature tolerance of now.
+func checkSignatureTime(headerName, timestamp string) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return InvalidSignature{Header: headerName, Reason: "timestamp is missing or invalid"}
+	}
+	age := time.Since(time.Unix(seconds, 0))
+	if tolerance := signatureTolerance(); age > tolerance || age < -tolerance {
+		return InvalidSignature{Header: headerName, Reason: "timestamp is outside the tolerance"}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package web_request_readers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Radiobox/web_request_readers/readertest"
+)
+
+func testMAC(secret, message string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+func TestVerifySignature(t *testing.T) {
+	const body, secret = `{"id":1}`, "s3cret"
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	hexMAC := func(message string) string { return hex.EncodeToString(testMAC(secret, message)) }
+
+	for _, test := range []struct {
+		name    string
+		scheme  SignatureScheme
+		value   string
+		slackTS string
+		valid   bool
+	}{
+		{"hex", SignatureHex, hexMAC(body), "", true},
+		{"hex with the wrong secret", SignatureHex, hex.EncodeToString(testMAC("other", body)), "", false},
+		{"base64", SignatureBase64, base64.StdEncoding.EncodeToString(testMAC(secret, body)), "", true},
+		{"url-safe base64", SignatureBase64, base64.RawURLEncoding.EncodeToString(testMAC(secret, body)), "", true},
+		{"github", SignatureGitHub, "sha256=" + hexMAC(body), "", true},
+		{"github without prefix", SignatureGitHub, hexMAC(body), "", false},
+		{"stripe", SignatureStripe, "t=" + now + ",v1=" + hexMAC(now+"."+body), "", true},
+		{"stripe while rolling secrets", SignatureStripe, "t=" + now + ",v1=" + hex.EncodeToString(testMAC("old", now+"."+body)) + ",v1=" + hexMAC(now+"."+body), "", true},
+		{"stripe with a stale timestamp", SignatureStripe, "t=" + stale + ",v1=" + hexMAC(stale+"."+body), "", false},
+		{"stripe without v1", SignatureStripe, "t=" + now + ",v0=" + hexMAC(now+"."+body), "", false},
+		{"slack", SignatureSlack, "v0=" + hexMAC("v0:"+now+":"+body), now, true},
+		{"slack with a stale timestamp", SignatureSlack, "v0=" + hexMAC("v0:"+stale+":"+body), stale, false},
+		{"slack without prefix", SignatureSlack, hexMAC("v0:" + now + ":" + body), now, false},
+	} {
+		ctx := readertest.NewContext(readertest.JSONRequest(t, "POST", "/hook", body))
+		ctx.Request.Header.Set(test.scheme.defaultHeader(), test.value)
+		if test.slackTS != "" {
+			ctx.Request.Header.Set("X-Slack-Request-Timestamp", test.slackTS)
+		}
+		err := VerifySignature(ctx, []byte(secret), "", test.scheme)
+		var invalid InvalidSignature
+		switch {
+		case test.valid && err != nil:
+			t.Errorf("%s: rejected a valid signature: %v", test.name, err)
+		case !test.valid && !errors.As(err, &invalid):
+			t.Errorf("%s: expected an InvalidSignature, got %v", test.name, err)
+		}
+	}
+}
+
+func TestVerifySignatureRequiresSecret(t *testing.T) {
+	const body = `{"id":1}`
+	for _, secret := range [][]byte{nil, {}} {
+		ctx := readertest.NewContext(readertest.JSONRequest(t, "POST", "/hook", body))
+		ctx.Request.Header.Set("X-Signature", hex.EncodeToString(testMAC("", body)))
+		if err := VerifySignature(ctx, secret, "X-Signature", SignatureHex); !errors.Is(err, ErrSignatureConfig) {
+			t.Errorf("accepted a signature made without a secret: %v", err)
+		}
+	}
+}
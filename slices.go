@@ -0,0 +1,184 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// A MixedArrayPolicy decides what happens when a request array with
+// elements of different types (e.g. [1, "two", 3]) is unmarshalled to
+// a typed slice.
+type MixedArrayPolicy int
+
+const (
+	// MixedArrayConvert converts each element to the slice's
+	// element type using the usual conversion rules, falling back
+	// to the element's string representation for string slices.
+	// An element that still can't be converted is an error.  This
+	// is the default.
+	MixedArrayConvert MixedArrayPolicy = iota
+
+	// MixedArrayError rejects arrays whose elements are not all of
+	// the same type, before any conversion is attempted.
+	MixedArrayError
+
+	// MixedArraySkip converts what it can and silently drops any
+	// elements that can't be converted.
+	MixedArraySkip
+)
+
+// maxNumericKeyElements caps the length of slices built from objects
+// with numeric keys, so that {"1000000000": 1} can't be used to make
+// us allocate a huge slice.
+const maxNumericKeyElements = 10000
+
+// setSlice unmarshals a request array (or, with NumericKeySlices, an
+// object with numeric keys) to a slice field one element at a time.
+func (state *unmarshalState) setSlice(target reflect.Value, value interface{}) error {
+	elems, err := state.sliceElements(value)
+	if err != nil {
+		return err
+	}
+	policy := state.unmarshaler.MixedArrays
+	if policy == MixedArrayError && len(elems) > 1 {
+		first := reflect.TypeOf(elems[0])
+		for index, elem := range elems[1:] {
+			if reflect.TypeOf(elem) != first {
+				return fmt.Errorf("Array element %d has type %T, but element 0 has type %s", index+1, elem, first)
+			}
+		}
+	}
+
+	elemType := target.Type().Elem()
+	if isStructType(elemType) {
+		return state.setStructSlice(target, elems)
+	}
+	slice := reflect.MakeSlice(target.Type(), 0, len(elems))
+	for index, elem := range elems {
+		elemValue := reflect.New(elemType).Elem()
+		err := state.setValue(elemValue, elem)
+		if err != nil && policy == MixedArrayConvert && elemType.Kind() == reflect.String && elem != nil {
+			elemValue.SetString(fmt.Sprint(elem))
+			err = nil
+		}
+		if err != nil {
+			if policy == MixedArraySkip {
+				continue
+			}
+			return fmt.Errorf("Cannot convert array element %d: %s", index, err)
+		}
+		slice = reflect.Append(slice, elemValue)
+	}
+	target.Set(slice)
+	return nil
+}
+
+// isStructType returns whether t is a struct or a pointer to one.
+func isStructType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// setStructSlice unmarshals a request array of objects to a slice of
+// structs.  Each element is unmarshalled like a nested struct whose
+// key is its index, e.g. "items[2]", so errors and missing fields name
+// the element they came from.  Every element is attempted, and all of
+// their errors are returned together as FieldErrors.
+func (state *unmarshalState) setStructSlice(target reflect.Value, elems []interface{}) error {
+	name, goName := state.fieldName, state.goFieldName
+	defer func() {
+		state.fieldName, state.goFieldName = name, goName
+	}()
+
+	var errs FieldErrors
+	slice := reflect.MakeSlice(target.Type(), len(elems), len(elems))
+	for index, elem := range elems {
+		state.fieldName = fmt.Sprintf("%s[%d]", name, index)
+		state.goFieldName = fmt.Sprintf("%s[%d]", goName, index)
+		err := state.setValue(slice.Index(index), elem)
+		if err == nil {
+			continue
+		}
+		if nested, ok := err.(nestedError); ok {
+			err = nested.err
+		}
+		switch elemErr := err.(type) {
+		case FieldError:
+			errs = append(errs, elemErr)
+		case FieldErrors:
+			errs = append(errs, elemErr...)
+		default:
+			errs = append(errs, FieldError{
+				Field:   state.keyPath + state.fieldName,
+				Code:    "invalid",
+				Message: err.Error(),
+				Err:     err,
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return nestedError{errs}
+	}
+	target.Set(slice)
+	return nil
+}
+
+// sliceElements returns the elements of a request value that is
+// being unmarshalled to a slice.
+func (state *unmarshalState) sliceElements(value interface{}) ([]interface{}, error) {
+	switch src := value.(type) {
+	case []interface{}:
+		return src, nil
+	case []string:
+		elems := make([]interface{}, len(src))
+		for index, elem := range src {
+			elems[index] = elem
+		}
+		return elems, nil
+	case map[string]interface{}:
+		if state.unmarshaler.NumericKeySlices {
+			return numericKeyElements(src)
+		}
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String && state.unmarshaler.NumericKeySlices {
+		msi := make(map[string]interface{}, rv.Len())
+		for _, key := range rv.MapKeys() {
+			msi[key.String()] = rv.MapIndex(key).Interface()
+		}
+		return numericKeyElements(msi)
+	}
+	return nil, fmt.Errorf("Cannot convert value of type %T to a slice", value)
+}
+
+// numericKeyElements converts an object like {"0": "a", "1": "b"} to
+// the ordered elements [a b].  The keys must be exactly the indexes
+// 0 through len-1; sparse or non-numeric keys are an error.
+func numericKeyElements(src map[string]interface{}) ([]interface{}, error) {
+	if len(src) > maxNumericKeyElements {
+		return nil, fmt.Errorf("Cannot convert object with more than %d keys to a slice", maxNumericKeyElements)
+	}
+	indexes := make([]int, 0, len(src))
+	byIndex := make(map[int]interface{}, len(src))
+	for key, elem := range src {
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("Cannot convert object with key %q to a slice", key)
+		}
+		indexes = append(indexes, index)
+		byIndex[index] = elem
+	}
+	sort.Ints(indexes)
+	elems := make([]interface{}, len(indexes))
+	for position, index := range indexes {
+		if index != position {
+			return nil, fmt.Errorf("Cannot convert object to a slice: missing index %d", position)
+		}
+		elems[position] = byIndex[index]
+	}
+	return elems, nil
+}
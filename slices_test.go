@@ -0,0 +1,88 @@
+package web_request_readers
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/objx"
+)
+
+type testTagged struct {
+	IDs  []int    `request:"ids,optional"`
+	Tags []string `request:"tags,optional"`
+}
+
+func TestMixedArrayPolicies(t *testing.T) {
+	mixed := []interface{}{1.0, "2", "three"}
+	for _, test := range []struct {
+		policy MixedArrayPolicy
+		key    string
+		want   testTagged
+		fails  bool
+	}{
+		{MixedArrayConvert, "tags", testTagged{Tags: []string{"1", "2", "three"}}, false},
+		{MixedArrayConvert, "ids", testTagged{}, true},
+		{MixedArrayError, "tags", testTagged{}, true},
+		{MixedArrayError, "ids", testTagged{}, true},
+		{MixedArraySkip, "ids", testTagged{IDs: []int{1, 2}}, false},
+		{MixedArraySkip, "tags", testTagged{Tags: []string{"2", "three"}}, false},
+	} {
+		unmarshaler := &Unmarshaler{MixedArrays: test.policy}
+		var got testTagged
+		err := unmarshaler.UnmarshalParams(objx.Map{test.key: mixed}, &got)
+		if test.fails {
+			if err == nil {
+				t.Errorf("policy %d, %s: accepted %v as %+v", test.policy, test.key, mixed, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("policy %d, %s: got %+v, %v; want %+v", test.policy, test.key, got, err, test.want)
+		}
+	}
+
+	// Arrays of one type are fine under every policy.
+	for _, policy := range []MixedArrayPolicy{MixedArrayConvert, MixedArrayError, MixedArraySkip} {
+		var got testTagged
+		err := (&Unmarshaler{MixedArrays: policy}).UnmarshalParams(objx.Map{"ids": []interface{}{1.0, 2.0}}, &got)
+		if err != nil || !reflect.DeepEqual(got.IDs, []int{1, 2}) {
+			t.Errorf("policy %d: got %v, %v", policy, got.IDs, err)
+		}
+	}
+}
+
+func TestNumericKeySlices(t *testing.T) {
+	tooMany := make(map[string]interface{}, maxNumericKeyElements+1)
+	for index := 0; index <= maxNumericKeyElements; index++ {
+		tooMany[strconv.Itoa(index)] = "x"
+	}
+	for _, test := range []struct {
+		name    string
+		enabled bool
+		value   interface{}
+		want    []string
+		fails   bool
+	}{
+		{"dense", true, map[string]interface{}{"1": "b", "0": "a", "2": "c"}, []string{"a", "b", "c"}, false},
+		{"dense objx", true, objx.Map{"0": "a", "1": "b"}, []string{"a", "b"}, false},
+		{"sparse", true, map[string]interface{}{"0": "a", "2": "c"}, nil, true},
+		{"negative", true, map[string]interface{}{"-1": "a"}, nil, true},
+		{"not numeric", true, map[string]interface{}{"0": "a", "x": "b"}, nil, true},
+		{"too many", true, tooMany, nil, true},
+		{"disabled", false, map[string]interface{}{"0": "a"}, nil, true},
+	} {
+		unmarshaler := &Unmarshaler{NumericKeySlices: test.enabled}
+		var got testTagged
+		err := unmarshaler.UnmarshalParams(objx.Map{"tags": test.value}, &got)
+		if test.fails {
+			if err == nil {
+				t.Errorf("%s: accepted as %v", test.name, got.Tags)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got.Tags, test.want) {
+			t.Errorf("%s: got %v, %v; want %v", test.name, got.Tags, err, test.want)
+		}
+	}
+}
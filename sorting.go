@@ -0,0 +1,57 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stretchr/objx"
+)
+
+// SortParam is the name of the parameter that ParseSort reads sort
+// orders from.  The expected format is a comma-separated list of
+// field names, each optionally prefixed with "-" for descending
+// order, e.g. sort=-created,name.
+var SortParam = "sort"
+
+// A SortField is a single field in a requested sort order.
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// ParseSort reads the sort order from params.  Every field must be in
+// allowed; an empty result is returned if the parameter is missing.
+func ParseSort(params objx.Map, allowed ...string) ([]SortField, error) {
+	value, ok := params[SortParam]
+	if !ok {
+		return nil, nil
+	}
+	switch src := value.(type) {
+	case string:
+		return parseSortValue(src, allowed)
+	case []string:
+		return parseSortValue(strings.Join(src, ","), allowed)
+	}
+	return nil, fmt.Errorf("Cannot read %s from value of type %T", SortParam, value)
+}
+
+// parseSortValue parses a comma-separated sort order.
+func parseSortValue(value string, allowed []string) ([]SortField, error) {
+	var fields []SortField
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		field := SortField{Field: name}
+		if strings.HasPrefix(name, "-") {
+			field = SortField{Field: name[1:], Descending: true}
+		}
+		if !containsString(allowed, field.Field) {
+			return nil, fmt.Errorf("Cannot sort on field %s; allowed fields are: %s",
+				field.Field, strings.Join(allowed, ","))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
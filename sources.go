@@ -0,0 +1,208 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// sourceHeader is the value of the "source" tag option for fields
+// that are read from a request header, rather than from the params,
+// e.g.
+//
+//	IfMatch ETagSet `request:"If-Match,source=header,optional"`
+//
+// The field's key (and aliases) name the header, which is matched
+// case-insensitively.  Repeated headers are joined with ", ", as list
+// headers may be.  time.Time fields are parsed as HTTP dates, e.g. for
+// If-Modified-Since, and numbers and bools may be read from the
+// header's string.  Header fields don't count as matching params, so
+// a body key with the same name is still reported as extra.
+const sourceHeader = "header"
+
+// sourceAuth is the value of the "source" tag option for fields that
+// are read from the request's Authorization header (see
+// ParseAuthorization).  Credentials fields receive the whole
+// Credentials; other fields receive the part named by their key:
+//
+//	type Login struct {
+//		Username string `request:"username,source=auth"`
+//		Password string `request:"password,source=auth"`
+//	}
+//
+// The parts are "scheme", "username", "password", and "token".  Parts
+// that the request's scheme doesn't have are missing, as is every
+// part when there is no Authorization header.
+const sourceAuth = "auth"
+
+// sourceExperiment is the value of the "source" tag option for fields
+// that are read from the request's experiment assignments (see
+// ParseAssignments).  Assignments fields receive all of them; other
+// fields receive the variant of the experiment named by their key:
+//
+//	type Checkout struct {
+//		Layout string `request:"checkout_layout,source=experiment"`
+//	}
+//
+// Fields are missing when the request isn't assigned to their
+// experiment.
+const sourceExperiment = "experiment"
+
+// A ValueSource finds values for fields in a request, for fields whose
+// "source" tag option names it (see RegisterSource).  It returns false
+// if the request has no value for key, which makes the field missing.
+// Errors are reported as FieldErrors for the field, with the "invalid"
+// code; errors that implement StatusCoder keep their status.
+type ValueSource func(r *http.Request, key string) (interface{}, bool, error)
+
+var valueSources = make(map[string]ValueSource)
+
+// RegisterSource registers a source of field values, which fields
+// select with the "source" tag option, e.g.
+//
+//	Subject string `request:"subject,source=claims,name=sub"`
+//
+// The source is asked for the field's key, then for each of its
+// aliases, unless the field has the "name" option, which names the
+// only key to ask for.  Sources can only be used when the request is
+// known, i.e. by Bind, UnmarshalRequestParams, and BindRequest.  The
+// "header", "auth", and "experiment" sources are built in, and can't
+// be replaced.
+// Like RegisterConverter, RegisterSource should be called before any
+// requests are handled.
+func RegisterSource(name string, source ValueSource) {
+	valueSources[name] = source
+}
+
+// sourceValue finds the value for a field with the "source" tag
+// option, trying each of the field's keys in order.
+func (state *unmarshalState) sourceValue(source string, keys []string, fieldType reflect.Type) (interface{}, bool, error) {
+	switch source {
+	case sourceHeader:
+		value, ok := state.headerValue(keys, fieldType)
+		return value, ok, nil
+	case sourceAuth:
+		return state.authValue(keys, fieldType)
+	case sourceExperiment:
+		return state.experimentValue(keys, fieldType)
+	}
+	valueSource, ok := valueSources[source]
+	if !ok {
+		return nil, false, fmt.Errorf("Unknown source %s", source)
+	}
+	request := state.httpRequest()
+	if request == nil {
+		return nil, false, nil
+	}
+	for _, key := range keys {
+		if value, ok, err := valueSource(request, key); err != nil || ok {
+			return value, ok, err
+		}
+	}
+	return nil, false, nil
+}
+
+// httpRequest returns the request being unmarshalled, if it is known.
+func (state *unmarshalState) httpRequest() *http.Request {
+	if state.request != nil {
+		return state.request
+	}
+	if state.ctx != nil {
+		return state.ctx.HttpRequest()
+	}
+	return nil
+}
+
+// headerValue finds the value for a source=header field, trying each
+// of the field's keys in order.
+func (state *unmarshalState) headerValue(keys []string, fieldType reflect.Type) (interface{}, bool) {
+	request := state.httpRequest()
+	if request == nil {
+		return nil, false
+	}
+	for _, key := range keys {
+		values := request.Header.Values(key)
+		if len(values) == 0 {
+			continue
+		}
+		value := strings.Join(values, ", ")
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType == reflect.TypeOf(time.Time{}) {
+			if parsed, err := http.ParseTime(value); err == nil {
+				return parsed, true
+			}
+		}
+		return value, true
+	}
+	return nil, false
+}
+
+// authValue finds the value for a source=auth field.
+func (state *unmarshalState) authValue(keys []string, fieldType reflect.Type) (interface{}, bool, error) {
+	request := state.httpRequest()
+	if request == nil || request.Header.Get("Authorization") == "" {
+		return nil, false, nil
+	}
+	credentials, err := ParseAuthorization(request.Header.Get("Authorization"))
+	if err != nil {
+		return nil, false, err
+	}
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType == reflect.TypeOf(Credentials{}) {
+		return credentials, true, nil
+	}
+	for _, key := range keys {
+		var part string
+		switch strings.ToLower(key) {
+		case "scheme":
+			part = credentials.Scheme
+		case "username":
+			part = credentials.Username
+		case "password":
+			part = credentials.Password
+		case "token":
+			part = credentials.Token
+		default:
+			return nil, false, fmt.Errorf("Unknown credential %s", key)
+		}
+		if part != "" {
+			return part, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// experimentValue finds the value for a source=experiment field.
+// Assignments are cached in ctx.Data() when the context is known.
+func (state *unmarshalState) experimentValue(keys []string, fieldType reflect.Type) (interface{}, bool, error) {
+	var assignments Assignments
+	var err error
+	if state.ctx != nil {
+		assignments, err = ParseAssignments(state.ctx)
+	} else if request := state.httpRequest(); request != nil {
+		assignments, err = parseAssignments(request)
+	} else {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType == reflect.TypeOf(Assignments{}) {
+		return assignments, true, nil
+	}
+	for _, key := range keys {
+		if variant, ok := assignments[key]; ok {
+			return variant, true, nil
+		}
+	}
+	return nil, false, nil
+}
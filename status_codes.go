@@ -0,0 +1,176 @@
+package web_request_readers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// A StatusCoder is an error that knows the HTTP status code it should
+// be reported with.  Most of this package's error types implement it,
+// and SuggestedStatus uses it for any error that does, so custom
+// errors (e.g. from a PreUnmarshal method) can choose their own
+// status.
+type StatusCoder interface {
+	error
+	StatusCode() int
+}
+
+// SuggestedStatus returns the HTTP status code for a response to a
+// request that failed with err, so that handlers can map errors to
+// responses in one call:
+//
+//	400 Bad Request for bodies that can't be parsed, or that aren't
+//	    objects, and for ClientErrors, InvalidParam, and
+//	    SpamDetected
+//	401 Unauthorized for InvalidSignature
+//	403 Forbidden for ForbiddenFields and CSRFError
+//	406 Not Acceptable for NotAcceptable
+//	413 Request Entity Too Large for bodies over Options.MaxBodySize
+//	415 Unsupported Media Type for UnsupportedMediaType and
+//	    FileTypeError
+//	422 Unprocessable Entity for values that parsed but aren't
+//	    valid: MissingFields, ExtraFields, FieldErrors, and the
+//	    errors that UnmarshalValue returns for a single value
+//	426 Upgrade Required for UpgradeRequired
+//	500 Internal Server Error for anything else, including receiver
+//	    errors that aren't ClientErrors
+//
+// It returns 200 OK for a nil error.
+func SuggestedStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	var (
+		coder    StatusCoder
+		tooLarge *http.MaxBytesError
+		syntax   *json.SyntaxError
+		typeErr  *json.UnmarshalTypeError
+		client   ClientError
+		mismatch TypeMismatch
+		rangeErr RangeError
+		format   InvalidFormat
+		inexact  InexactNumber
+		enumErr  InvalidEnumValue
+	)
+	switch {
+	case errors.As(err, &coder):
+		return coder.StatusCode()
+	case errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.As(err, &syntax), errors.As(err, &typeErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return http.StatusBadRequest
+	case errors.As(err, &client) && client.ClientError():
+		return http.StatusBadRequest
+	case errors.As(err, &mismatch), errors.As(err, &rangeErr), errors.As(err, &format),
+		errors.As(err, &inexact), errors.As(err, &enumErr):
+		// Returned on their own by UnmarshalValue.
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// StatusCode returns 400 Bad Request.
+func (err WrongBodyShape) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// StatusCode returns 415 Unsupported Media Type.
+func (err UnsupportedMediaType) StatusCode() int {
+	return http.StatusUnsupportedMediaType
+}
+
+// StatusCode returns 400 Bad Request.
+func (err DuplicateKeys) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// StatusCode returns 422 Unprocessable Entity.
+func (err MissingFields) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// StatusCode returns 422 Unprocessable Entity.
+func (err ExtraFields) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// StatusCode returns 403 Forbidden.
+func (err ForbiddenFields) StatusCode() int {
+	return http.StatusForbidden
+}
+
+// StatusCode returns the StatusCode of the field's error, if it has
+// one (e.g. 500 Internal Server Error for a receiver that failed with
+// an internal error), and 422 Unprocessable Entity otherwise.
+func (err FieldError) StatusCode() int {
+	var coder StatusCoder
+	if errors.As(err.Err, &coder) {
+		return coder.StatusCode()
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// StatusCode returns the highest StatusCode of the errors, so that
+// one internal error makes the whole request an internal error.
+func (errs FieldErrors) StatusCode() int {
+	status := http.StatusUnprocessableEntity
+	for _, err := range errs {
+		if code := err.StatusCode(); code > status {
+			status = code
+		}
+	}
+	return status
+}
+
+// StatusCode returns 415 Unsupported Media Type.
+func (err FileTypeError) StatusCode() int {
+	return http.StatusUnsupportedMediaType
+}
+
+// StatusCode returns 400 Bad Request.
+func (err InvalidFilter) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// StatusCode returns 400 Bad Request.
+func (err InvalidParam) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// StatusCode returns 400 Bad Request.
+func (err InvalidAssignment) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// StatusCode returns 426 Upgrade Required.
+func (err UpgradeRequired) StatusCode() int {
+	return http.StatusUpgradeRequired
+}
+
+// StatusCode returns 401 Unauthorized.
+func (err InvalidSignature) StatusCode() int {
+	return http.StatusUnauthorized
+}
+
+// StatusCode returns 406 Not Acceptable.
+func (err NotAcceptable) StatusCode() int {
+	return http.StatusNotAcceptable
+}
+
+// StatusCode returns 500 Internal Server Error, since an invalid
+// target is a bug in the handler rather than in the request.
+func (err InvalidTargetError) StatusCode() int {
+	return http.StatusInternalServerError
+}
+
+// StatusCode returns 422 Unprocessable Entity for errors caused by
+// the request (see IsClientError), and 500 Internal Server Error for
+// anything else.
+func (err ReceiverError) StatusCode() int {
+	if err.IsClientError() {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,54 @@
+package web_request_readers
+
+// Strictness decides how forgiving an Unmarshaler is about request
+// values that don't have exactly the type of their field.
+type Strictness int
+
+const (
+	// Lenient allows the Unmarshaler's Coercions (by default, "42"
+	// for numeric fields) and its TruncateFloats option.  This is
+	// the default, and suits form data, where every value is a
+	// string.
+	Lenient Strictness = iota
+
+	// Strict allows no coercions and no truncation, as if every
+	// field had the "strict" tag option and TruncateFloats were
+	// false.  It suits JSON bodies, where clients can send proper
+	// types.  Fields can still opt back in with the "weak",
+	// "coerce", and "truncate" tag options.
+	Strict
+
+	// StrictnessByDecoder picks Lenient or Strict for each request
+	// from the decoder that ParseBody used for its body, using
+	// DecoderStrictness.  Unmarshals that don't know their request
+	// (e.g. plain UnmarshalParams) are Lenient.
+	StrictnessByDecoder
+)
+
+// DecoderStrictness maps ParsedContent.Decoder names to the
+// Strictness used for StrictnessByDecoder.  Decoders that aren't
+// listed are Lenient.  It should only be changed before any requests
+// are handled.
+var DecoderStrictness = map[string]Strictness{
+	JSONDecoder:       Strict,
+	MergePatchDecoder: Strict,
+	JSONPatchDecoder:  Strict,
+}
+
+// applyStrictness applies the unmarshaler's Strictness to the state.
+func (state *unmarshalState) applyStrictness() {
+	strictness := state.unmarshaler.Strictness
+	if strictness == StrictnessByDecoder {
+		decoder := state.decoder
+		if decoder == "" && state.ctx != nil {
+			if content, ok := ParsedContentOf(state.ctx); ok {
+				decoder = content.Decoder
+			}
+		}
+		strictness = DecoderStrictness[decoder]
+	}
+	if strictness == Strict {
+		state.strict = true
+		state.coercions = StrictTyping.resolve()
+	}
+}
@@ -0,0 +1,72 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// normalizeString applies the string normalization tag options to a
+// request value:
+//
+//	trim       strips leading and trailing whitespace
+//	lower      converts the value to lower case
+//	upper      converts the value to upper case
+//	maxlen=N   limits the value to N characters
+//
+// Values that are too long are an error, unless the field also has
+// the "truncate" option or the Unmarshaler's TruncateStrings option is
+// set, in which case they are cut down to N characters.  Options are
+// applied in the order above, so whitespace doesn't count towards
+// maxlen.  Values that aren't strings are returned unchanged.
+func (state *unmarshalState) normalizeString(args []string, value interface{}) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok || len(args) == 0 {
+		return value, nil
+	}
+	original := str
+	if containsString(args, "trim") {
+		str = strings.TrimSpace(str)
+	}
+	if containsString(args, "lower") {
+		str = strings.ToLower(str)
+	} else if containsString(args, "upper") {
+		str = strings.ToUpper(str)
+	}
+	if option, ok := tagOption(args, "maxlen"); ok {
+		maxLen, err := strconv.Atoi(option)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid maxlen option %s", option)
+		}
+		if utf8.RuneCountInString(str) > maxLen {
+			if !state.unmarshaler.TruncateStrings && !containsString(args, "truncate") {
+				return nil, fmt.Errorf("Must have at most %d characters", maxLen)
+			}
+			str = string([]rune(str)[:maxLen])
+		}
+	}
+	if str == original {
+		// Reboxing an unchanged string would allocate.
+		return value, nil
+	}
+	return str, nil
+}
+
+// checkPattern checks a string value against a field's pattern
+// option, e.g. pattern=^[a-z0-9_-]+$.  Since tag options are
+// separated by commas, patterns can't contain commas.  Values that
+// aren't strings aren't checked.
+func checkPattern(meta *fieldMeta, value interface{}) error {
+	if meta.patternErr != nil {
+		return fmt.Errorf("Invalid pattern option: %s", meta.patternErr)
+	}
+	str, ok := value.(string)
+	if !ok || meta.pattern == nil {
+		return nil
+	}
+	if !meta.pattern.MatchString(str) {
+		return fmt.Errorf("Must match the pattern %s", meta.pattern)
+	}
+	return nil
+}
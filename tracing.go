@@ -0,0 +1,90 @@
+package web_request_readers
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// A Tracer is told when ParseBody and UnmarshalParams start and
+// finish, so that instrumentation (such as the otel sub-package) can
+// record them without every call site being wrapped.  Each Start
+// method returns a function that is called with the outcome when the
+// operation finishes.
+type Tracer interface {
+	// StartParseBody is called before a request body is decoded,
+	// with the request's context and the media type from its
+	// Content-Type header.  The returned function is given the
+	// number of bytes read from the body and the error, if any.
+	StartParseBody(ctx gocontext.Context, mime string) func(size int, err error)
+
+	// StartUnmarshal is called before params are unmarshalled to
+	// target, with the context passed to UnmarshalParamsCtx (or the
+	// request's context for UnmarshalRequestParams, or else
+	// context.Background()).  The returned function is given the
+	// error, if any.  Nested structs and the elements of
+	// UnmarshalSlice are not traced separately.
+	StartUnmarshal(ctx gocontext.Context, target interface{}) func(err error)
+}
+
+// SetTracer atomically changes the Tracer used by ParseBody and
+// UnmarshalParams.  A nil Tracer turns tracing off.
+func SetTracer(tracer Tracer) {
+	updateConfig(func(c *Config) { c.Tracer = tracer })
+}
+
+// ErrorClass returns a short, stable name for the kind of error that
+// err is (or wraps), for use in traces and metrics: e.g.
+// "missing_fields", "field", or "syntax".  It returns "" for a nil
+// error and "other" for errors it doesn't recognize.
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	var (
+		invalidTarget InvalidTargetError
+		missing       MissingFields
+		extra         ExtraFields
+		forbidden     ForbiddenFields
+		duplicates    DuplicateKeys
+		nesting       NestingError
+		receiver      ReceiverError
+		fieldErr      FieldError
+		fieldErrs     FieldErrors
+		unsupported   UnsupportedMediaType
+		wrongShape    WrongBodyShape
+		tooLarge      *http.MaxBytesError
+		syntax        *json.SyntaxError
+		typeErr       *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.Is(err, gocontext.Canceled), errors.Is(err, gocontext.DeadlineExceeded):
+		return "canceled"
+	case errors.As(err, &invalidTarget):
+		return "invalid_target"
+	case errors.As(err, &unsupported):
+		return "unsupported_media_type"
+	case errors.As(err, &wrongShape):
+		return "wrong_body_shape"
+	case errors.As(err, &tooLarge):
+		return "body_too_large"
+	case errors.As(err, &syntax), errors.As(err, &typeErr):
+		return "syntax"
+	case errors.As(err, &duplicates):
+		return "duplicate_keys"
+	case errors.As(err, &nesting):
+		return "nesting"
+	case errors.As(err, &receiver):
+		return "receiver"
+	case errors.As(err, &fieldErrs), errors.As(err, &fieldErr):
+		return "field"
+	case errors.As(err, &forbidden):
+		return "forbidden_fields"
+	case errors.As(err, &extra):
+		return "extra_fields"
+	case errors.As(err, &missing):
+		return "missing_fields"
+	}
+	return "other"
+}
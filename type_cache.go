@@ -0,0 +1,75 @@
+package web_request_readers
+
+import (
+	"reflect"
+	"regexp"
+	"sync"
+)
+
+// fieldMeta holds everything about a struct field that can be worked
+// out from its type alone, so that it only has to be worked out once
+// per type rather than once per request.
+type fieldMeta struct {
+	// name, args, and tagged are the results of nameAndArgs.
+	name   string
+	args   []string
+	tagged bool
+
+	// keys is name followed by the field's aliases.  Callers must
+	// not modify it.
+	keys []string
+
+	// pattern is the compiled value of the field's pattern
+	// option, or nil if it doesn't have one.  patternErr is set
+	// instead if the pattern doesn't compile.
+	pattern    *regexp.Regexp
+	patternErr error
+}
+
+// typeMetaKey identifies the metadata of a struct type as read using
+// a particular tag name.
+type typeMetaKey struct {
+	structType reflect.Type
+	tagName    string
+}
+
+// typeMetas caches the []*fieldMeta of each struct type that has been
+// unmarshalled to, indexed by field.
+var typeMetas sync.Map
+
+// fieldMetas returns the metadata for each field of structType, read
+// from its tagName tags.
+func fieldMetas(structType reflect.Type, tagName string) []*fieldMeta {
+	key := typeMetaKey{structType: structType, tagName: tagName}
+	if metas, ok := typeMetas.Load(key); ok {
+		return metas.([]*fieldMeta)
+	}
+	metas := make([]*fieldMeta, structType.NumField())
+	for i := range metas {
+		meta := new(fieldMeta)
+		meta.name, meta.args, meta.tagged = nameAndArgsFor(structType.Field(i), tagName)
+		meta.keys = append([]string{meta.name}, tagOptions(meta.args, "alias")...)
+		if expr, ok := tagOption(meta.args, "pattern"); ok {
+			meta.pattern, meta.patternErr = regexp.Compile(expr)
+		}
+		metas[i] = meta
+	}
+	// If another goroutine got here first, use its copy so that
+	// everyone shares the same compiled patterns.
+	actual, _ := typeMetas.LoadOrStore(key, metas)
+	return actual.([]*fieldMeta)
+}
+
+// hasMethods returns whether t, or anything it points to, or a pointer
+// to any of those, has exported methods.  Values of types without
+// methods can't implement any of the interfaces that setValue looks
+// for.
+func hasMethods(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		if t.NumMethod() > 0 {
+			return true
+		}
+		t = t.Elem()
+	}
+	return t.NumMethod() > 0 || reflect.PointerTo(t).NumMethod() > 0
+}
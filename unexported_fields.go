@@ -0,0 +1,35 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// UnexportedField is an error type for a struct field that has a
+// request tag but can't be set, because it is unexported.  It is only
+// returned when Unmarshaler.RejectUnexportedTags is set; otherwise
+// such fields are silently ignored, like any other unexported field.
+type UnexportedField struct {
+	// Struct is the name of the struct type, and Field is the name
+	// of the unexported field.
+	Struct string
+	Field  string
+}
+
+// Error returns the error message for an UnexportedField error.
+func (err UnexportedField) Error() string {
+	return fmt.Sprintf("Field %s of %s has a request tag but is unexported", err.Field, err.Struct)
+}
+
+// checkUnexported returns an UnexportedField error for fieldType if
+// it is tagged with the tag being read and the unmarshaler rejects
+// unexported tagged fields.
+func (state *unmarshalState) checkUnexported(structType reflect.Type, fieldType reflect.StructField) error {
+	if !state.unmarshaler.RejectUnexportedTags {
+		return nil
+	}
+	if _, tagged := fieldType.Tag.Lookup(state.tagName); !tagged {
+		return nil
+	}
+	return UnexportedField{Struct: structType.String(), Field: fieldType.Name}
+}
@@ -0,0 +1,68 @@
+package web_request_readers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A UnitConverter converts a value from one unit to another.
+type UnitConverter func(float64) float64
+
+var unitConverters = map[string]UnitConverter{
+	"mm->m":          func(v float64) float64 { return v / 1000 },
+	"cm->m":          func(v float64) float64 { return v / 100 },
+	"km->m":          func(v float64) float64 { return v * 1000 },
+	"g->kg":          func(v float64) float64 { return v / 1000 },
+	"ms->s":          func(v float64) float64 { return v / 1000 },
+	"cents->dollars": func(v float64) float64 { return v / 100 },
+}
+
+// RegisterUnitConverter registers a converter for the unit tag
+// option.  Once registered, a field tagged with
+//
+//	request:"height,unit=cm->m"
+//
+// will have its request value converted from "from" units to "to"
+// units before it is assigned.  The arrow may also be written as "→".
+func RegisterUnitConverter(from, to string, converter UnitConverter) {
+	unitConverters[from+"->"+to] = converter
+}
+
+// convertUnit converts a request value using the converter named by a
+// unit tag option.  The result is always a float64, which setValue
+// knows how to assign to any numeric field.
+func convertUnit(unit string, value interface{}) (float64, error) {
+	unit = strings.Replace(unit, "→", "->", 1)
+	converter, ok := unitConverters[unit]
+	if !ok {
+		return 0, fmt.Errorf("No unit converter registered for %s", unit)
+	}
+	var num float64
+	switch src := value.(type) {
+	case string:
+		parsed, err := strconv.ParseFloat(src, 64)
+		if err != nil {
+			return 0, err
+		}
+		num = parsed
+	case json.Number:
+		parsed, err := src.Float64()
+		if err != nil {
+			return 0, err
+		}
+		num = parsed
+	case float64:
+		num = src
+	case float32:
+		num = float64(src)
+	case int:
+		num = float64(src)
+	case int64:
+		num = float64(src)
+	default:
+		return 0, fmt.Errorf("Cannot convert units of a %T value", value)
+	}
+	return converter(num), nil
+}
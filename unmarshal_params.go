@@ -5,12 +5,16 @@
 package web_request_readers
 
 import (
+	gocontext "context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"log/slog"
 	"reflect"
-	"strconv"
 	"strings"
-	"unicode"
+	"time"
 
+	"github.com/stretchr/goweb/context"
 	"github.com/stretchr/objx"
 )
 
@@ -32,6 +36,11 @@ const (
 // If you set it to false, then you must add "required" to a field's
 // "request" tag if you want to receive errors when it has no
 // corresponding value in a request.
+//
+// Deprecated: Use SetDefaultRequired.  Assignments to DefaultRequired
+// are only seen until the configuration is first changed with
+// SetConfig or any of its setters, after which Config.DefaultRequired
+// is used and the variable is ignored.  See CurrentConfig.
 var DefaultRequired = true
 
 // UnmarshalParams takes a series of parameters and unmarshals them to
@@ -48,8 +57,8 @@ var DefaultRequired = true
 // If a tag is found and has a value of "-", the field will be
 // skipped.
 //
-// The target value *must* be a pointer to a struct, or the function
-// will panic.
+// The target value *must* be a pointer to a struct (or to an
+// Unmarshaller), or an InvalidTargetError will be returned.
 //
 // The returned error will be nil if there was a value in the request
 // that matched every parseable (i.e. exported field not tagged with
@@ -62,8 +71,9 @@ var DefaultRequired = true
 // populated during a request.
 //
 // If there were values in the request that could not be matched to
-// fields in the struct, or if any other unexpected error happens, the
-// return value will be a generic error type.
+// fields in the struct, the returned error will be of type
+// ExtraFields.  If any other unexpected error happens, the return
+// value will be a generic error type.
 //
 // A simple example:
 //
@@ -88,7 +98,123 @@ var DefaultRequired = true
 //         }
 //         return target, nil
 //     }
-func UnmarshalParams(params objx.Map, target interface{}) (unmarshalErr error) {
+//
+// UnmarshalParams uses DefaultUnmarshaler.  If you need different
+// options in different parts of your code, create your own
+// Unmarshaler and call its UnmarshalParams method instead.
+func UnmarshalParams(params objx.Map, target interface{}) error {
+	return DefaultUnmarshaler.UnmarshalParams(params, target)
+}
+
+// UnmarshalRequestParams is like UnmarshalParams, but has access to
+// the request that params came from.  This allows fields to use
+// ContextDefaultValueCreator to pick defaults based on the request.
+func UnmarshalRequestParams(ctx context.Context, params objx.Map, target interface{}) error {
+	return DefaultUnmarshaler.UnmarshalRequestParams(ctx, params, target)
+}
+
+// UnmarshalParamsCtx is like UnmarshalParams, but carries ctx through
+// the unmarshal.  ctx is passed to any ContextReceiver,
+// ContextPreReceiver, or ContextPostReceiver fields (e.g. receivers
+// that look up a slug in a database), and the unmarshal stops with
+// ctx.Err() if ctx is cancelled or its deadline passes.
+func UnmarshalParamsCtx(ctx gocontext.Context, params objx.Map, target interface{}) error {
+	return DefaultUnmarshaler.UnmarshalParamsCtx(ctx, params, target)
+}
+
+// UnmarshalParamsCtx unmarshals params to target using the
+// unmarshaler's options.  See the package-level UnmarshalParamsCtx
+// for details.
+func (unmarshaler *Unmarshaler) UnmarshalParamsCtx(ctx gocontext.Context, params objx.Map, target interface{}) error {
+	state := unmarshaler.newState(nil, params)
+	defer state.release()
+	state.goCtx = ctx
+	return unmarshaler.unmarshal(state, target)
+}
+
+// UnmarshalParamsResult is like UnmarshalParams, but also returns a
+// Result describing what happened during the unmarshal.  The Result
+// is returned even when there is an error.
+func UnmarshalParamsResult(params objx.Map, target interface{}) (*Result, error) {
+	return DefaultUnmarshaler.UnmarshalParamsResult(params, target)
+}
+
+// UnmarshalParamsPatch is like UnmarshalParams, but for PATCH-style
+// requests: only the keys that are present in params are applied.
+// Missing fields are left unchanged (no defaults, no MissingFields
+// error), and a key that is present with a null value sets its field
+// to the zero value, even for non-pointer fields.
+func UnmarshalParamsPatch(params objx.Map, target interface{}) error {
+	return DefaultUnmarshaler.UnmarshalParamsPatch(params, target)
+}
+
+// UnmarshalParamsPatch unmarshals params to target in patch mode,
+// using the unmarshaler's options.  See the package-level
+// UnmarshalParamsPatch for details.
+func (unmarshaler *Unmarshaler) UnmarshalParamsPatch(params objx.Map, target interface{}) error {
+	state := unmarshaler.newState(nil, params)
+	defer state.release()
+	state.patch = true
+	return unmarshaler.unmarshal(state, target)
+}
+
+// UnmarshalParams unmarshals params to target using the unmarshaler's
+// options.  See the package-level UnmarshalParams for details.
+func (unmarshaler *Unmarshaler) UnmarshalParams(params objx.Map, target interface{}) error {
+	return unmarshaler.UnmarshalMap(params, target)
+}
+
+// UnmarshalParamsResult unmarshals params to target using the
+// unmarshaler's options.  See the package-level UnmarshalParamsResult
+// for details.
+func (unmarshaler *Unmarshaler) UnmarshalParamsResult(params objx.Map, target interface{}) (*Result, error) {
+	state := unmarshaler.newState(nil, params)
+	defer state.release()
+	result := &Result{Trace: state.result.Trace}
+	state.result, state.recordChanges = result, true
+	err := unmarshaler.unmarshal(state, target)
+	if result.Defaults == nil {
+		result.Defaults = make(map[string]interface{})
+	}
+	return result, err
+}
+
+// UnmarshalRequestParams unmarshals params to target using the
+// unmarshaler's options.  See the package-level
+// UnmarshalRequestParams for details.
+func (unmarshaler *Unmarshaler) UnmarshalRequestParams(ctx context.Context, params objx.Map, target interface{}) error {
+	state := unmarshaler.newState(ctx, params)
+	defer state.release()
+	return unmarshaler.unmarshal(state, target)
+}
+
+// unmarshal is the shared implementation of the UnmarshalParams
+// variants.
+func (unmarshaler *Unmarshaler) unmarshal(state *unmarshalState, target interface{}) (unmarshalErr error) {
+	if state.keyPath == "" {
+		// Slice elements have a keyPath, and are traced as part
+		// of UnmarshalSlice rather than one at a time.
+		if state.tracer != nil {
+			finish := state.tracer.StartUnmarshal(state.requestContext(), target)
+			defer func() { finish(unmarshalErr) }()
+		}
+		if len(unmarshalledHooks) > 0 {
+			start := time.Now()
+			defer func() { runUnmarshalledHooks(target, start, unmarshalErr) }()
+		}
+	}
+	if err := checkTarget(target); err != nil {
+		return err
+	}
+	state.applyStrictness()
+	state.applyOptions()
+	if state.stringValues {
+		state.coercions |= CoerceStringToNumber | CoerceStringToBool
+	}
+	if err := state.checkKeyCollisions(); err != nil {
+		return err
+	}
+	params := state.params
 	preUnmarshaller, hasPreUnmarshal := target.(PreUnmarshaller)
 	unmarshaller, hasUnmarshal := target.(Unmarshaller)
 	postUnmarshaller, hasPostUnmarshal := target.(PostUnmarshaller)
@@ -96,8 +222,13 @@ func UnmarshalParams(params objx.Map, target interface{}) (unmarshalErr error) {
 	ptrValue := reflect.ValueOf(target)
 	targetValue := ptrValue.Elem()
 
-	// If interfaces weren't found, try again with the element
-	targetElem := targetValue.Interface()
+	// If interfaces weren't found, try again with the element.
+	// The element's methods are a subset of the pointer's, so it
+	// is only boxed (which allocates) if it has any.
+	var targetElem interface{}
+	if targetValue.Type().NumMethod() > 0 {
+		targetElem = targetValue.Interface()
+	}
 	if !hasPreUnmarshal {
 		preUnmarshaller, hasPreUnmarshal = targetElem.(PreUnmarshaller)
 	}
@@ -124,16 +255,83 @@ func UnmarshalParams(params objx.Map, target interface{}) (unmarshalErr error) {
 		return unmarshaller.Unmarshal(params)
 	}
 
-	missingErr := new(MissingFields)
-	matchedFields, err := unmarshalToValue(params, targetValue, missingErr)
+	if messages, ok := target.(FieldMessages); ok {
+		state.messages = messages.Messages()
+	} else if messages, ok := targetElem.(FieldMessages); ok {
+		state.messages = messages.Messages()
+	}
+
+	validator, hasValidate := target.(CrossValidator)
+	if !hasValidate {
+		validator, hasValidate = targetElem.(CrossValidator)
+	}
+	if hasValidate {
+		state.changed = make(map[string]interface{})
+	}
+
+	recorder, hasRecorder := target.(ChangeRecorder)
+	if !hasRecorder {
+		recorder, hasRecorder = targetElem.(ChangeRecorder)
+	}
+	if hasRecorder {
+		state.recordChanges = true
+	}
+
+	matchedFields, err := state.unmarshalToValue(targetValue)
 	if err != nil {
 		return err
 	}
 
-	if matchedFields < len(params) {
-		return errors.New("More parameters passed than this model has fields.")
-	} else if missingErr.HasMissingFields() {
-		return *missingErr
+	if hasRecorder {
+		recorder.RecordChangedFields(state.result.ChangedFields)
+	}
+
+	computer, hasCompute := target.(FieldComputer)
+	if !hasCompute {
+		computer, hasCompute = targetElem.(FieldComputer)
+	}
+	if hasCompute {
+		// Computed fields are still derived when values are missing,
+		// since PATCH-style handlers routinely ignore MissingFields.
+		if err := computer.ComputeFields(); err != nil {
+			return err
+		}
+	}
+
+	if hasValidate {
+		if err := validator.ValidateFields(state.changed); err != nil {
+			return err
+		}
+	}
+
+	if !state.consumed[UserAgentKey] && state.injectedUserAgent() {
+		// See Config.InjectUserAgent.
+		state.consumed[UserAgentKey] = true
+		matchedFields++
+	}
+	extraParams := matchedFields < len(params) && !state.ignoreExtra
+	var extra ExtraFields
+	if extraParams {
+		extra = state.extraFields("")
+		state.logDebug("unknown request keys",
+			slog.Any("keys", extra.Names),
+			slog.String("target", fmt.Sprintf("%T", target)))
+	}
+	if unmarshaler.ReplayDefaults && params != nil {
+		for key, value := range state.result.Defaults {
+			// Keys are paths from the outermost params, e.g.
+			// "[2].price" for slice elements, and setParamPath
+			// follows the rest of the path into nested params.
+			setParamPath(params, strings.TrimPrefix(key, state.keyPath), value)
+		}
+	}
+
+	if state.forbidden.HasForbiddenFields() {
+		return *state.forbidden
+	} else if extraParams {
+		return extra
+	} else if state.missing.HasMissingFields() {
+		return *state.missing
 	}
 	return nil
 }
@@ -151,12 +349,27 @@ func getNextOption(remainingTag string) (string, string) {
 	return nextOption, remaining
 }
 
+// NameAndArgs returns the request key for a struct field, along with
+// the options from its "request" tag.
 func NameAndArgs(fieldType reflect.StructField) (string, []string) {
-	tag := fieldType.Tag.Get("request")
+	name, args, _ := nameAndArgs(fieldType)
+	return name, args
+}
+
+// nameAndArgs is NameAndArgs, but also reports whether the name came
+// from a tag (as opposed to the field's name).
+func nameAndArgs(fieldType reflect.StructField) (name string, args []string, tagged bool) {
+	return nameAndArgsFor(fieldType, DefaultTagName)
+}
+
+// nameAndArgsFor is nameAndArgs, reading keys and options from the
+// tagName tag instead of "request".
+func nameAndArgsFor(fieldType reflect.StructField, tagName string) (name string, args []string, tagged bool) {
+	tag := fieldType.Tag.Get(tagName)
 	name, remaining := getNextOption(tag)
 
 	// A capacity of 5 seems like a sane default.
-	args := make([]string, 0, 5)
+	args = make([]string, 0, 5)
 	var next string
 	for remaining != "" {
 		next, remaining = getNextOption(remaining)
@@ -164,42 +377,84 @@ func NameAndArgs(fieldType reflect.StructField) (string, []string) {
 	}
 
 	if name != "" {
-		return name, args
+		return name, args, true
 	}
 	if name = fieldType.Tag.Get("response"); name != "" {
-		return name, args
+		return name, args, true
 	}
 	// Fall back to db tag if it isn't "-"
 	if name = fieldType.Tag.Get("db"); name != "" && name != "-" {
-		return name, args
+		return name, args, true
 	}
 
-	return strings.ToLower(fieldType.Name), args
+	return strings.ToLower(fieldType.Name), args, false
 }
 
 // unmarshalToValue is a helper for UnmarshalParams, which keeps track
 // of the total number of fields matched in a request and which fields
 // were missing from a request.
-func unmarshalToValue(params objx.Map, targetValue reflect.Value, missingErr *MissingFields) (matchedFields int, parseErr error) {
+func (state *unmarshalState) unmarshalToValue(targetValue reflect.Value) (matchedFields int, parseErr error) {
 	targetType := targetValue.Type()
+	metas := fieldMetas(targetType, state.tagName)
 	for i := 0; i < targetValue.NumField() && parseErr == nil; i++ {
+		if state.goCtx != nil {
+			if parseErr = state.goCtx.Err(); parseErr != nil {
+				return
+			}
+		}
 		field := targetValue.Field(i)
 		fieldType := targetType.Field(i)
-		if fieldType.Anonymous {
+		if prefix, ok := state.embeddedPrefix(fieldType, metas[i]); ok {
 			var embeddedCount int
-			embeddedCount, parseErr = unmarshalToValue(params, field, missingErr)
+			target, allocated := embeddedTarget(field)
+			restore := state.withPrefix(prefix, fieldType)
+			embeddedCount, parseErr = state.unmarshalToValue(target)
+			restore()
+			if allocated && (embeddedCount > 0 || !target.IsZero()) {
+				field.Set(target.Addr())
+			}
 			matchedFields += embeddedCount
 			continue
 		}
 
 		// Skip unexported fields
-		if unicode.IsUpper(rune(fieldType.Name[0])) {
-			name, args := NameAndArgs(fieldType)
+		if !fieldType.IsExported() {
+			parseErr = state.checkUnexported(targetType, fieldType)
+			if _, tagged := fieldType.Tag.Lookup(state.tagName); tagged {
+				state.trace(fieldType.Name, "", TraceIgnored, "unexported")
+			}
+		} else {
+			meta := metas[i]
+			name, args := meta.name, meta.args
+			keys, fold := meta.keys, false
+			// With the default KeyMatcher (LowerKeys), the
+			// cached keys are already right.
+			if !meta.tagged && state.unmarshaler.KeyMatcher != nil {
+				keys, fold = state.unmarshaler.untaggedKeys(fieldType.Name)
+				name = keys[0]
+				keys = append(keys, meta.keys[1:]...)
+			}
 			switch name {
 			case "-":
+				state.trace(fieldType.Name, "", TraceIgnored, "tagged -")
 				continue
 			default:
-				required := DefaultRequired
+				// Headers and credentials aren't prefixed
+				// like params.
+				sourceKeys := keys
+				source, _ := tagOption(args, "source")
+				if sourceName, ok := tagOption(args, "name"); ok && source != "" {
+					sourceKeys = []string{sourceName}
+				}
+				if state.prefix != "" {
+					// meta.keys is shared, so prefix a copy.
+					prefixed := make([]string, len(keys))
+					for index, key := range keys {
+						prefixed[index] = state.prefix + key
+					}
+					keys, name = prefixed, prefixed[0]
+				}
+				required := state.defaultRequired && !isOptionalType(fieldType.Type)
 				for _, arg := range args {
 					if arg == "optional" {
 						required = false
@@ -207,13 +462,89 @@ func unmarshalToValue(params objx.Map, targetValue reflect.Value, missingErr *Mi
 						required = true
 					}
 				}
-				if value, ok := params[name]; ok {
-					matchedFields++
-					parseErr = setValue(field, value)
-				} else if required {
-					missingErr.AddMissingField(name)
-				} else if defaulter, ok := field.Interface().(DefaultValueCreator); ok {
-					setValue(field, defaulter.DefaultValue())
+				conditional := false
+				if !required && state.requiredByCondition(args) {
+					required, conditional = true, true
+				}
+				canSet, skip := state.canSet(state.keyPath+name, args)
+				if !canSet {
+					required = false
+				}
+				value, ok := state.lookup(keys, fold)
+				if source != "" {
+					var sourceErr error
+					if value, ok, sourceErr = state.sourceValue(source, sourceKeys, fieldType.Type); sourceErr != nil {
+						parseErr = state.fieldError(name, args, "invalid", nil,
+							fmt.Errorf("%w for field %s", sourceErr, name))
+						continue
+					}
+				}
+				if ok {
+					var key string
+					if source != "" {
+						key = sourceKeys[0]
+					} else {
+						// Aliases that lost out to an earlier
+						// key were still expected, so they
+						// count as matched rather than as
+						// extra params.
+						matchedFields += state.countKeys(keys, fold)
+						if state.result.Trace != nil {
+							key = state.matchedKey(keys, fold)
+						}
+					}
+					if skip {
+						state.trace(fieldType.Name, key, TraceSkipped, "out of scope")
+						continue
+					}
+					if !canSet {
+						state.trace(fieldType.Name, key, TraceForbidden, "")
+						state.forbidden.AddForbiddenField(state.keyPath + name)
+						continue
+					}
+					if parseErr = state.checkDuplicateValues(field, name, args, value); parseErr != nil {
+						state.trace(fieldType.Name, key, TraceFailed, parseErr.Error())
+						continue
+					}
+					if source != "" {
+						// Header and credential values are
+						// always strings.
+						restore := state.withCoercions(state.coercions | CoerceStringToNumber | CoerceStringToBool)
+						parseErr = state.setField(field, fieldType, name, meta, value)
+						restore()
+					} else {
+						parseErr = state.setField(field, fieldType, name, meta, value)
+					}
+					if parseErr != nil {
+						state.trace(fieldType.Name, key, TraceFailed, parseErr.Error())
+					} else {
+						state.trace(fieldType.Name, key, TraceSet, "")
+					}
+					if state.changed != nil {
+						state.changed[fieldType.Name] = field.Interface()
+					}
+					if state.recordChanges {
+						state.result.ChangedFields = append(state.result.ChangedFields, state.fieldPath+fieldType.Name)
+					}
+				} else if state.patch {
+					state.trace(fieldType.Name, state.keyPath+name, TraceAbsent, "patch")
+					continue
+				} else {
+					defaultValue, hasDefault := state.defaultFor(field)
+					if hasDefault {
+						state.setDefault(field, name, defaultValue)
+						state.trace(fieldType.Name, state.keyPath+name, TraceDefaulted, fmt.Sprint(defaultValue))
+					}
+					if required && !(hasDefault && state.unmarshaler.DefaultsSatisfyRequired) {
+						state.missing.AddMissing(MissingField{
+							RequestKey: state.keyPath + name,
+							FieldPath:  state.fieldPath + fieldType.Name,
+							Required:   !conditional,
+						})
+						state.trace(fieldType.Name, state.keyPath+name, TraceMissing, "")
+					} else if !hasDefault {
+						state.trace(fieldType.Name, state.keyPath+name, TraceAbsent, "")
+					}
 				}
 			}
 		}
@@ -221,26 +552,195 @@ func unmarshalToValue(params objx.Map, targetValue reflect.Value, missingErr *Mi
 	return
 }
 
+// setField applies any tag options that transform a request value
+// and then sets the field to the result.
+func (state *unmarshalState) setField(field reflect.Value, fieldType reflect.StructField, name string, meta *fieldMeta, value interface{}) error {
+	args := meta.args
+	if unit, ok := tagOption(args, "unit"); ok && value != nil {
+		converted, err := convertUnit(unit, value)
+		if err != nil {
+			return state.fieldError(name, args, "unit", value,
+				fmt.Errorf("Cannot convert units for field %s: %s", name, err))
+		}
+		value = converted
+	}
+	normalized, err := state.normalizeString(args, value)
+	if err != nil {
+		return state.fieldError(name, args, "maxlen", value, err)
+	}
+	value = normalized
+	if err := checkPattern(meta, value); err != nil {
+		return state.fieldError(name, args, "pattern", value, err)
+	}
+	if choices, ok := tagOption(args, "enum"); ok && value != nil {
+		if err := checkEnumOption(choices, value); err != nil {
+			return state.fieldError(name, args, "enum", value, err)
+		}
+	}
+	previousName, previousGoName := state.fieldName, state.goFieldName
+	state.fieldName, state.goFieldName = name, fieldType.Name
+	defer func() {
+		state.fieldName, state.goFieldName = previousName, previousGoName
+	}()
+	if unitName, ok := tagOption(args, "duration"); ok {
+		unit, known := durationUnits[unitName]
+		if !known {
+			return state.fieldError(name, args, "invalid", value,
+				fmt.Errorf("Unknown duration unit %s for field %s", unitName, name))
+		}
+		previousUnit := state.durationUnit
+		state.durationUnit = unit
+		defer func() { state.durationUnit = previousUnit }()
+	}
+	if containsString(args, "raw") {
+		previousRaw := state.rawBytes
+		state.rawBytes = true
+		defer func() { state.rawBytes = previousRaw }()
+	}
+	if containsString(args, "truncate") {
+		previousTruncate := state.truncate
+		state.truncate = true
+		defer func() { state.truncate = previousTruncate }()
+	}
+	if containsString(args, "exact") {
+		previousExact := state.exactNumbers
+		state.exactNumbers = true
+		defer func() { state.exactNumbers = previousExact }()
+	}
+	if names, ok := tagOption(args, "coerce"); ok {
+		coercions, err := parseCoercions(names)
+		if err != nil {
+			return state.fieldError(name, args, "invalid", value,
+				fmt.Errorf("%s for field %s", err, name))
+		}
+		defer state.withCoercions(coercions)()
+	} else if containsString(args, "weak") {
+		defer state.withCoercions(WeakTyping)()
+	} else if containsString(args, "strict") {
+		defer state.withCoercions(StrictTyping)()
+	}
+	if err := state.setValue(field, value); err != nil {
+		if nested, ok := err.(nestedError); ok {
+			// Errors from nested structs already describe
+			// their own fields.
+			return nested.err
+		}
+		code := "invalid"
+		switch err.(type) {
+		case TypeMismatch:
+			code = "type"
+		case InvalidEnumValue:
+			code = "enum"
+		case NestingError:
+			code = "depth"
+		case InvalidFormat:
+			code = "format"
+		case InexactNumber:
+			code = "exact"
+		case RangeError:
+			code = "range"
+		}
+		return state.fieldError(name, args, code, value, err)
+	}
+	if code, err := checkLimits(field, args); err != nil {
+		return state.fieldError(name, args, code, value, err)
+	}
+	if code, err := runValidators(field, args); err != nil {
+		return state.fieldError(name, args, code, value, err)
+	}
+	return nil
+}
+
+// setDefault sets a field to its default value and records the
+// default in the result.
+func (state *unmarshalState) setDefault(field reflect.Value, name string, value interface{}) {
+	state.setValue(field, value)
+	if state.result.Defaults == nil {
+		state.result.Defaults = make(map[string]interface{})
+	}
+	state.result.Defaults[state.keyPath+name] = value
+	state.logDebug("applied default value",
+		slog.String("field", state.keyPath+name),
+		slog.Any("value", value))
+}
+
+// tagOption finds the value of a name=value option in a field's tag
+// args.
+func tagOption(args []string, name string) (string, bool) {
+	prefix := name + "="
+	for _, arg := range args {
+		if strings.HasPrefix(arg, prefix) {
+			return arg[len(prefix):], true
+		}
+	}
+	return "", false
+}
+
+// tagOptions finds the values of every name=value option in a field's
+// tag args, for options that may be repeated.
+func tagOptions(args []string, name string) []string {
+	prefix := name + "="
+	var values []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, prefix) {
+			values = append(values, arg[len(prefix):])
+		}
+	}
+	return values
+}
+
 // setValue takes a target and a value, and updates the target to
 // match the value.
-func setValue(target reflect.Value, value interface{}) (parseErr error) {
+func (state *unmarshalState) setValue(target reflect.Value, value interface{}) (parseErr error) {
+	if optional, ok := asOptional(target); ok {
+		return optional.receiveOptional(state, value)
+	}
 	if value == nil {
-		if target.Kind() != reflect.Ptr {
+		if scanner, ok := asScanner(target); ok && target.Kind() != reflect.Ptr {
+			return scanner.Scan(nil)
+		}
+		if target.Kind() != reflect.Ptr && !state.patch {
 			return errors.New("Cannot set non-pointer value to null")
 		}
-		if !target.IsNil() {
+		if target.Kind() != reflect.Ptr || !target.IsNil() {
 			target.Set(reflect.Zero(target.Type()))
 		}
 		return nil
 	}
+	if handled, err := state.setConverted(target, value); handled {
+		return err
+	}
 
 	if target.Kind() == reflect.Ptr && target.IsNil() {
 		target.Set(reflect.New(target.Type().Elem()))
+		switch target.Type().Elem().Kind() {
+		case reflect.Slice, reflect.Map:
+			// A pointer to a slice or map is only non-nil if it
+			// was sent, so don't leave an empty one behind when
+			// the value can't be converted.
+			defer func() {
+				if parseErr != nil {
+					target.Set(reflect.Zero(target.Type()))
+				}
+			}()
+		}
+	}
+	if !hasMethods(target.Type()) {
+		// There's nothing to look for, so skip straight to the
+		// plain conversions rather than boxing target for every
+		// interface check.
+		return state.setPlainValue(target, value)
 	}
 
 	preReceiver, hasPreReceive := target.Interface().(PreReceiver)
 	receiver, hasReceive := target.Interface().(RequestValueReceiver)
+	namedReceiver, hasNamedReceive := target.Interface().(NamedReceiver)
 	postReceiver, hasPostReceive := target.Interface().(PostReceiver)
+	ctxPreReceiver, hasCtxPreReceive := target.Interface().(ContextPreReceiver)
+	ctxReceiver, hasCtxReceive := target.Interface().(ContextReceiver)
+	ctxPostReceiver, hasCtxPostReceive := target.Interface().(ContextPostReceiver)
+	valuePreReceiver, hasValuePreReceive := target.Interface().(ValuePreReceiver)
+	valuePostReceiver, hasValuePostReceive := target.Interface().(ValuePostReceiver)
 	if target.CanAddr() {
 		// If interfaces weren't found, try again with the pointer
 		targetPtr := target.Addr().Interface()
@@ -250,25 +750,69 @@ func setValue(target reflect.Value, value interface{}) (parseErr error) {
 		if !hasReceive {
 			receiver, hasReceive = targetPtr.(RequestValueReceiver)
 		}
+		if !hasNamedReceive {
+			namedReceiver, hasNamedReceive = targetPtr.(NamedReceiver)
+		}
 		if !hasPostReceive {
 			postReceiver, hasPostReceive = targetPtr.(PostReceiver)
 		}
+		if !hasCtxPreReceive {
+			ctxPreReceiver, hasCtxPreReceive = targetPtr.(ContextPreReceiver)
+		}
+		if !hasCtxReceive {
+			ctxReceiver, hasCtxReceive = targetPtr.(ContextReceiver)
+		}
+		if !hasCtxPostReceive {
+			ctxPostReceiver, hasCtxPostReceive = targetPtr.(ContextPostReceiver)
+		}
+		if !hasValuePreReceive {
+			valuePreReceiver, hasValuePreReceive = targetPtr.(ValuePreReceiver)
+		}
+		if !hasValuePostReceive {
+			valuePostReceiver, hasValuePostReceive = targetPtr.(ValuePostReceiver)
+		}
 	}
 
-	if hasPreReceive {
-		if parseErr = preReceiver.PreReceive(); parseErr != nil {
+	if hasCtxPreReceive {
+		if parseErr = state.receiverError(ctxPreReceiver.PreReceiveContext(state.goContext())); parseErr != nil {
+			return
+		}
+	} else if hasValuePreReceive {
+		if parseErr = state.receiverError(valuePreReceiver.PreReceiveValue(value)); parseErr != nil {
+			return
+		}
+	} else if hasPreReceive {
+		if parseErr = state.receiverError(preReceiver.PreReceive()); parseErr != nil {
 			return
 		}
 	}
-	if hasPostReceive {
+	if hasCtxPostReceive {
 		defer func() {
 			if parseErr == nil {
-				parseErr = postReceiver.PostReceive()
+				parseErr = state.receiverError(ctxPostReceiver.PostReceiveContext(state.goContext()))
 			}
 		}()
+	} else if hasValuePostReceive {
+		defer func() {
+			if parseErr == nil {
+				parseErr = state.receiverError(valuePostReceiver.PostReceiveValue(value))
+			}
+		}()
+	} else if hasPostReceive {
+		defer func() {
+			if parseErr == nil {
+				parseErr = state.receiverError(postReceiver.PostReceive())
+			}
+		}()
+	}
+	if hasCtxReceive {
+		return state.receiverError(ctxReceiver.ReceiveContext(state.goContext(), value))
+	}
+	if hasNamedReceive {
+		return state.receiverError(namedReceiver.ReceiveNamed(state.fieldName, value, state.params))
 	}
 	if hasReceive {
-		return receiver.Receive(value)
+		return state.receiverError(receiver.Receive(value))
 	}
 
 	for target.Kind() == reflect.Ptr {
@@ -289,14 +833,73 @@ func setValue(target reflect.Value, value interface{}) (parseErr error) {
 			target = typeVal
 		}
 	}
+	if scanner, ok := asScanner(target); ok {
+		// Anything else that knows how to read itself from a
+		// database (guregu/null, pq, and custom nullable types) can
+		// most likely read itself from a request value as well.
+		return scanner.Scan(value)
+	}
+	if handled, err := setEncoded(target, value); handled {
+		return err
+	}
+	return state.setPlainValue(target, value)
+}
+
+// setPlainValue is the part of setValue that doesn't depend on any of
+// target's methods.
+func (state *unmarshalState) setPlainValue(target reflect.Value, value interface{}) (parseErr error) {
+	for target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+	if target.Kind() == reflect.Struct {
+		if params, ok := asParams(value); ok {
+			return state.setStruct(target, params)
+		}
+	}
+	if target.Kind() == reflect.Interface {
+		if handled, err := state.setPolymorphic(target, value); handled {
+			return err
+		}
+	}
+	if handled, err := setEnum(target, value); handled {
+		return err
+	}
+	defer func() {
+		if parseErr == nil {
+			parseErr = checkEnum(target, value)
+		}
+	}()
+	if target.Type() == durationType {
+		unit := state.durationUnit
+		if unit == 0 {
+			unit = state.unmarshaler.durationUnit()
+		}
+		return setDuration(target, value, unit)
+	}
+	if isBytesType(target.Type()) {
+		if str, ok := value.(string); ok {
+			return setBytes(target, str, state.rawBytes)
+		}
+	}
+	if value, parseErr = state.coerce(target, value); parseErr != nil {
+		return
+	}
 	switch target.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		parseErr = setInt(target, value)
+		parseErr = setInt(target, value, state.truncateNumbers())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parseErr = setUint(target, value, state.truncateNumbers())
 	case reflect.Float32, reflect.Float64:
 		parseErr = setFloat(target, value)
 	default:
 		inputType := reflect.TypeOf(value)
 		if !inputType.ConvertibleTo(target.Type()) {
+			if target.Kind() == reflect.Slice {
+				return state.setSlice(target, value)
+			}
+			if target.Kind() == reflect.Map {
+				return state.setMap(target, value)
+			}
 			parseErr = errors.New("Cannot convert value to target type")
 			return
 		}
@@ -305,54 +908,15 @@ func setValue(target reflect.Value, value interface{}) (parseErr error) {
 	return
 }
 
-func setInt(target reflect.Value, value interface{}) error {
-	switch src := value.(type) {
-	case string:
-		intVal, err := strconv.ParseInt(src, 10, 64)
-		if err != nil {
-			return err
-		}
-		target.SetInt(intVal)
-	case int:
-		target.SetInt(int64(src))
-	case int8:
-		target.SetInt(int64(src))
-	case int16:
-		target.SetInt(int64(src))
-	case int32:
-		target.SetInt(int64(src))
-	case int64:
-		target.SetInt(src)
-	case float32:
-		target.SetInt(int64(src))
-	case float64:
-		target.SetInt(int64(src))
+// asScanner returns target (or a pointer to it) as an sql.Scanner, if
+// it implements the interface.
+func asScanner(target reflect.Value) (sql.Scanner, bool) {
+	if scanner, ok := target.Interface().(sql.Scanner); ok {
+		return scanner, true
 	}
-	return nil
-}
-
-func setFloat(target reflect.Value, value interface{}) error {
-	switch src := value.(type) {
-	case string:
-		floatVal, err := strconv.ParseFloat(src, 64)
-		if err != nil {
-			return err
-		}
-		target.SetFloat(floatVal)
-	case int:
-		target.SetFloat(float64(src))
-	case int8:
-		target.SetFloat(float64(src))
-	case int16:
-		target.SetFloat(float64(src))
-	case int32:
-		target.SetFloat(float64(src))
-	case int64:
-		target.SetFloat(float64(src))
-	case float32:
-		target.SetFloat(float64(src))
-	case float64:
-		target.SetFloat(src)
+	if target.CanAddr() {
+		scanner, ok := target.Addr().Interface().(sql.Scanner)
+		return scanner, ok
 	}
-	return nil
+	return nil, false
 }
@@ -0,0 +1,129 @@
+package web_request_readers
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// UnmarshalSlice unmarshals a request body that is an array of
+// objects (e.g. the result of ParseBody for a JSON array) to target,
+// which must be a pointer to a slice of structs or of pointers to
+// structs.  Each element goes through the same steps as a target of
+// UnmarshalParams.
+//
+// Errors name the element they came from by its index, e.g.
+// "[2].price".  If any element has an error other than missing
+// fields, the returned error is of type FieldErrors and lists every
+// such error; otherwise, missing fields from all elements are
+// returned together as MissingFields.
+func UnmarshalSlice(body interface{}, target interface{}) error {
+	return DefaultUnmarshaler.UnmarshalSlice(body, target)
+}
+
+// UnmarshalSlice unmarshals an array body to target using the
+// unmarshaler's options.  See the package-level UnmarshalSlice for
+// details.
+func (unmarshaler *Unmarshaler) UnmarshalSlice(body interface{}, target interface{}) (sliceErr error) {
+	if tracer := CurrentConfig().Tracer; tracer != nil {
+		finish := tracer.StartUnmarshal(gocontext.Background(), target)
+		defer func() { finish(sliceErr) }()
+	}
+	if len(unmarshalledHooks) > 0 {
+		start := time.Now()
+		defer func() { runUnmarshalledHooks(target, start, sliceErr) }()
+	}
+	targetValue := reflect.ValueOf(target)
+	const expected = "a non-nil pointer to a slice of structs"
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() || targetValue.Elem().Kind() != reflect.Slice {
+		return invalidTarget("UnmarshalSlice", expected, target)
+	}
+	sliceType := targetValue.Elem().Type()
+	elemType := sliceType.Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return invalidTarget("UnmarshalSlice", expected, target)
+	}
+	elems, ok := body.([]interface{})
+	if !ok {
+		return fmt.Errorf("Cannot unmarshal a %T body to a slice", body)
+	}
+
+	var collected elementErrors
+	slice := reflect.MakeSlice(sliceType, len(elems), len(elems))
+	for index, elem := range elems {
+		key := fmt.Sprintf("[%d]", index)
+		params, ok := asParams(elem)
+		if !ok {
+			collected.errs = append(collected.errs, FieldError{
+				Field:   key,
+				Code:    "type",
+				Message: fmt.Sprintf("Element %d is not an object", index),
+			})
+			continue
+		}
+		elemPtr := reflect.New(elemType)
+		state := unmarshaler.newState(nil, params)
+		state.keyPath = key + "."
+		state.fieldPath = key + "."
+		err := unmarshaler.unmarshal(state, elemPtr.Interface())
+		state.release()
+		collected.add(key, err)
+
+		if isPtr {
+			slice.Index(index).Set(elemPtr)
+		} else {
+			slice.Index(index).Set(elemPtr.Elem())
+		}
+	}
+	targetValue.Elem().Set(slice)
+	return collected.err()
+}
+
+// elementErrors collects the errors of each element of a request
+// array (UnmarshalSlice's elements, or ParseBatch's operations), so
+// that all of them are reported at once.
+type elementErrors struct {
+	errs    FieldErrors
+	missing MissingFields
+}
+
+// add adds the error for the element named field, if there is one.
+// Missing fields and field errors keep their own names, which already
+// include the element's; other errors become an "invalid" FieldError
+// for the element.
+func (collected *elementErrors) add(field string, err error) {
+	var missing MissingFields
+	var fieldErrs FieldErrors
+	var fieldErr FieldError
+	switch {
+	case err == nil:
+	case errors.As(err, &missing):
+		for _, entry := range missing.Entries() {
+			collected.missing.AddMissing(entry)
+		}
+	case errors.As(err, &fieldErrs):
+		collected.errs = append(collected.errs, fieldErrs...)
+	case errors.As(err, &fieldErr):
+		collected.errs = append(collected.errs, fieldErr)
+	default:
+		collected.errs = append(collected.errs, FieldError{Field: field, Code: "invalid", Message: err.Error(), Err: err})
+	}
+}
+
+// err returns the collected FieldErrors, or if there are none, the
+// collected MissingFields, or nil.
+func (collected *elementErrors) err() error {
+	if len(collected.errs) > 0 {
+		return collected.errs
+	}
+	if collected.missing.HasMissingFields() {
+		return collected.missing
+	}
+	return nil
+}
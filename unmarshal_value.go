@@ -0,0 +1,34 @@
+package web_request_readers
+
+import (
+	"reflect"
+)
+
+// UnmarshalValue sets the value that target points to from a single
+// request value, using the same conversions that UnmarshalParams uses
+// for struct fields.  Tag options don't apply, since there is no
+// field to read them from.  It is mostly useful for generated
+// unmarshallers (see cmd/webreqgen), which use it for any field type
+// they don't convert themselves.
+func UnmarshalValue(value interface{}, target interface{}) error {
+	return DefaultUnmarshaler.UnmarshalValue(value, target)
+}
+
+// UnmarshalValue sets the value that target points to using the
+// unmarshaler's options.  See the package-level UnmarshalValue for
+// details.
+func (unmarshaler *Unmarshaler) UnmarshalValue(value interface{}, target interface{}) error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() {
+		return invalidTarget("UnmarshalValue", "a non-nil pointer", target)
+	}
+	state := unmarshaler.newState(nil, nil)
+	defer state.release()
+	if err := state.setValue(targetValue.Elem(), value); err != nil {
+		if nested, ok := err.(nestedError); ok {
+			return nested.err
+		}
+		return err
+	}
+	return nil
+}
@@ -21,3 +21,65 @@ type PreUnmarshaller interface {
 type PostUnmarshaller interface {
 	PostUnmarshal() error
 }
+
+// A FieldComputer is a type with fields that are derived from other
+// fields, such as a Slug derived from a Name, or a SearchText built
+// from several fields.  ComputeFields is called after all request
+// values have been unmarshalled (and before PostUnmarshal), so the
+// derivation logic lives with the model instead of in every handler.
+type FieldComputer interface {
+	ComputeFields() error
+}
+
+// A CrossValidator is a type with validation rules that involve more
+// than one field, such as "end_date must be after start_date" or
+// "either email or phone is required".  ValidateFields is called after
+// ComputeFields with the values of the fields that were set from the
+// request, keyed by struct field name, and its error is returned
+// as-is.  It is called even when fields are missing, so that rules
+// about optional fields can be enforced.
+type CrossValidator interface {
+	ValidateFields(changed map[string]interface{}) error
+}
+
+// A ChangeRecorder is a type that wants to know which of its fields
+// were actually present in a request, e.g. so that a PATCH handler can
+// build a partial UPDATE without reloading the record to diff against.
+// RecordChangedFields is called with the struct field names after all
+// request values have been unmarshalled.
+type ChangeRecorder interface {
+	RecordChangedFields(fields []string)
+}
+
+// ChangeTracker is a ChangeRecorder that can be embedded in a model
+// to get change tracking for free.
+//
+//	type User struct {
+//	    web_request_readers.ChangeTracker
+//	    Name  string
+//	    Email string
+//	}
+//
+//	// After UnmarshalParams, user.ChangedFields() returns e.g.
+//	// []string{"Email"} for a request that only sent an email.
+type ChangeTracker struct {
+	changed []string
+}
+
+// RecordChangedFields stores the fields that were present in a
+// request.
+func (tracker *ChangeTracker) RecordChangedFields(fields []string) {
+	tracker.changed = fields
+}
+
+// ChangedFields returns the struct field names that were present in
+// the request.
+func (tracker *ChangeTracker) ChangedFields() []string {
+	return tracker.changed
+}
+
+// Changed returns whether or not the named struct field was present
+// in the request.
+func (tracker *ChangeTracker) Changed(field string) bool {
+	return containsString(tracker.changed, field)
+}
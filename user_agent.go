@@ -0,0 +1,223 @@
+package web_request_readers
+
+import (
+	"strings"
+
+	"github.com/stretchr/goweb/context"
+	"github.com/stretchr/objx"
+)
+
+const (
+	userAgentDataKey         = "user_agent"
+	userAgentInjectedDataKey = "user_agent_injected"
+)
+
+// UserAgentKey is the reserved params key that ParseBody stores the
+// request's UserAgent under when Config.InjectUserAgent is set, so
+// that models can record it like any other field:
+//
+//	type Session struct {
+//		UserAgent web_request_readers.UserAgent `request:"_user_agent,optional"`
+//	}
+//
+// Any value the client sends under the key is replaced.  The key is
+// only exempt from ExtraFields when ParseBody injected it, so clients
+// can't use it to smuggle a value past an unmarshal.
+const UserAgentKey = "_user_agent"
+
+// Device classes, for UserAgent.Device.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+)
+
+// A UserAgent is the classification of a User-Agent header.  It is
+// deliberately coarse: the names of common browsers, operating
+// systems, and crawlers, and whether the client is a phone, a tablet,
+// or a bot.  Anything that isn't recognized is left empty.
+type UserAgent struct {
+	// Raw is the header, as it was sent.
+	Raw string
+
+	// Browser and BrowserVersion are e.g. "Chrome" and "120.0.0.0".
+	// For bots, Browser is the bot's name, e.g. "Googlebot".
+	Browser        string
+	BrowserVersion string
+
+	// OS and OSVersion are e.g. "iOS" and "17.1".
+	OS        string
+	OSVersion string
+
+	// Device is DeviceDesktop, DeviceMobile, DeviceTablet,
+	// DeviceBot, or "" for an empty header.
+	Device string
+
+	// Bot is true for crawlers, monitors, and HTTP libraries.
+	Bot bool
+}
+
+// botMarkers are substrings (matched case-insensitively) of the
+// User-Agents of clients that aren't people using browsers.
+var botMarkers = []string{"bot", "crawler", "spider", "slurp", "curl/", "wget/", "python-", "go-http-client", "okhttp", "java/", "headless"}
+
+// userAgentBrowsers are checked in order, since most browsers also
+// claim to be the ones before them (e.g. Edge sends "Chrome/" and
+// "Safari/" too).
+var userAgentBrowsers = []struct{ token, name string }{
+	{"Edg/", "Edge"},
+	{"EdgiOS/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Version/", "Safari"},
+	{"MSIE ", "Internet Explorer"},
+	{"Trident/", "Internet Explorer"},
+}
+
+// ParseUserAgent classifies a User-Agent header.
+func ParseUserAgent(raw string) UserAgent {
+	agent := UserAgent{Raw: raw}
+	if strings.TrimSpace(raw) == "" {
+		return agent
+	}
+	lower := strings.ToLower(raw)
+	for _, marker := range botMarkers {
+		if strings.Contains(lower, marker) {
+			agent.Bot, agent.Device = true, DeviceBot
+			agent.Browser, agent.BrowserVersion = botName(raw)
+			return agent
+		}
+	}
+
+	for _, browser := range userAgentBrowsers {
+		if version, ok := tokenVersion(raw, browser.token); ok {
+			if browser.name == "Safari" && !strings.Contains(raw, "Safari/") {
+				continue
+			}
+			agent.Browser, agent.BrowserVersion = browser.name, version
+			break
+		}
+	}
+	if agent.Browser == "Internet Explorer" && strings.HasPrefix(agent.BrowserVersion, "7.") {
+		// Trident/7 is IE 11.
+		agent.BrowserVersion = "11.0"
+	}
+
+	switch {
+	case strings.Contains(raw, "iPhone") || strings.Contains(raw, "iPad") || strings.Contains(raw, "iPod"):
+		agent.OS = "iOS"
+		if version, ok := tokenVersion(raw, "OS "); ok {
+			agent.OSVersion = strings.ReplaceAll(version, "_", ".")
+		}
+	case strings.Contains(raw, "Android"):
+		agent.OS = "Android"
+		agent.OSVersion, _ = tokenVersion(raw, "Android ")
+	case strings.Contains(raw, "Windows"):
+		agent.OS = "Windows"
+		agent.OSVersion, _ = tokenVersion(raw, "Windows NT ")
+	case strings.Contains(raw, "Mac OS X"):
+		agent.OS = "macOS"
+		if version, ok := tokenVersion(raw, "Mac OS X "); ok {
+			agent.OSVersion = strings.ReplaceAll(version, "_", ".")
+		}
+	case strings.Contains(raw, "CrOS"):
+		agent.OS = "ChromeOS"
+	case strings.Contains(raw, "Linux"):
+		agent.OS = "Linux"
+	}
+
+	switch {
+	case strings.Contains(raw, "iPad") || strings.Contains(raw, "Tablet") ||
+		(agent.OS == "Android" && !strings.Contains(raw, "Mobile")):
+		agent.Device = DeviceTablet
+	case strings.Contains(raw, "Mobi") || strings.Contains(raw, "iPhone") || strings.Contains(raw, "iPod"):
+		agent.Device = DeviceMobile
+	default:
+		agent.Device = DeviceDesktop
+	}
+	return agent
+}
+
+// tokenVersion returns the version that follows token in raw, e.g.
+// "120.0" for "Chrome/" in "... Chrome/120.0 Safari/537.36".
+func tokenVersion(raw, token string) (string, bool) {
+	index := strings.Index(raw, token)
+	if index == -1 {
+		return "", false
+	}
+	rest := raw[index+len(token):]
+	if end := strings.IndexAny(rest, " ;)"); end != -1 {
+		rest = rest[:end]
+	}
+	return rest, true
+}
+
+// botName returns the name and version of a bot, from the first
+// product token that looks like one, e.g. "Googlebot/2.1" in
+// "Mozilla/5.0 (compatible; Googlebot/2.1; ...)".
+func botName(raw string) (string, string) {
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ' ' || r == ';' || r == '(' || r == ')' || r == '+'
+	})
+	for _, field := range fields {
+		name, version, _ := strings.Cut(field, "/")
+		lower := strings.ToLower(name)
+		if name == "Mozilla" || name == "compatible" {
+			continue
+		}
+		for _, marker := range botMarkers {
+			if strings.Contains(lower+"/", marker) {
+				return name, version
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return raw, ""
+	}
+	name, version, _ := strings.Cut(fields[0], "/")
+	return name, version
+}
+
+// RequestUserAgent classifies the User-Agent header of a request.
+// The result is cached in ctx.Data().
+func RequestUserAgent(ctx context.Context) UserAgent {
+	if agent, ok := ctx.Data()[userAgentDataKey].(UserAgent); ok {
+		return agent
+	}
+	agent := ParseUserAgent(ctx.HttpRequest().UserAgent())
+	ctx.Data().Set(userAgentDataKey, agent)
+	return agent
+}
+
+// injectUserAgent stores the request's UserAgent in params, replacing
+// anything the client sent under UserAgentKey, and records in ctx that
+// it did so.
+func injectUserAgent(ctx context.Context, params objx.Map) {
+	params[UserAgentKey] = RequestUserAgent(ctx)
+	ctx.Data()[userAgentInjectedDataKey] = true
+}
+
+// injectedUserAgent returns whether the params being unmarshalled
+// hold a UserAgent injected by ParseBody.  Without a request to check,
+// a UserAgent value is enough, since decoded bodies never hold one.
+func (state *unmarshalState) injectedUserAgent() bool {
+	if _, ok := state.params[UserAgentKey].(UserAgent); !ok || state.keyPath != "" {
+		return false
+	}
+	if state.ctx == nil {
+		return true
+	}
+	injected, _ := state.ctx.Data()[userAgentInjectedDataKey].(bool)
+	return injected
+}
+
+// SetInjectUserAgent atomically changes whether ParseBody adds the
+// request's UserAgent to its params.  See Config.InjectUserAgent.
+func SetInjectUserAgent(inject bool) {
+	updateConfig(func(c *Config) { c.InjectUserAgent = inject })
+}
@@ -0,0 +1,44 @@
+package web_request_readers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Radiobox/web_request_readers/readertest"
+)
+
+type testComment struct {
+	Body string `request:"body"`
+}
+
+func TestUserAgentKeyOnlyExemptWhenInjected(t *testing.T) {
+	parse := func() (*readertest.Context, map[string]interface{}) {
+		ctx := readertest.NewContext(readertest.JSONRequest(t, "POST", "/", `{"body": "hi", "_user_agent": "forged"}`))
+		ctx.Request.Header.Set("User-Agent", "curl/8.0")
+		params, err := ParseParams(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ctx, params
+	}
+
+	ctx, params := parse()
+	var extra ExtraFields
+	if err := UnmarshalRequestParams(ctx, params, &testComment{}); !errors.As(err, &extra) {
+		t.Errorf("client-sent %s was accepted without injection: %v", UserAgentKey, err)
+	}
+
+	SetInjectUserAgent(true)
+	defer SetInjectUserAgent(false)
+	ctx, params = parse()
+	agent, ok := params[UserAgentKey].(UserAgent)
+	if !ok || agent.Raw != "curl/8.0" {
+		t.Fatalf("client-sent %s was not replaced: %#v", UserAgentKey, params[UserAgentKey])
+	}
+	if err := UnmarshalRequestParams(ctx, params, &testComment{}); err != nil {
+		t.Errorf("injected %s was reported: %v", UserAgentKey, err)
+	}
+	if err := UnmarshalParams(params, &testComment{}); err != nil {
+		t.Errorf("injected %s was reported without a request: %v", UserAgentKey, err)
+	}
+}
@@ -0,0 +1,56 @@
+// Package uuid registers web_request_readers converters for the UUID
+// types of github.com/google/uuid and github.com/gofrs/uuid, so that
+// ID fields read directly from request strings:
+//
+//	uuid.Install()
+//
+//	type GetUser struct {
+//		ID googleuuid.UUID `request:"id"`
+//	}
+//
+// Strings that aren't UUIDs are rejected with a
+// web_request_readers.InvalidFormat error.
+package uuid
+
+import (
+	web_request_readers "github.com/Radiobox/web_request_readers"
+	gofrsuuid "github.com/gofrs/uuid/v5"
+	googleuuid "github.com/google/uuid"
+)
+
+// format is the InvalidFormat.Format of errors for strings that
+// aren't UUIDs.
+const format = "UUID"
+
+// Install registers converters for googleuuid.UUID,
+// googleuuid.NullUUID, gofrsuuid.UUID, and gofrsuuid.NullUUID.  Like
+// web_request_readers.RegisterConverter, it should be called before
+// any requests are handled.
+func Install() {
+	web_request_readers.RegisterConverter(parseGoogle)
+	web_request_readers.RegisterConverter(func(value string) (googleuuid.NullUUID, error) {
+		id, err := parseGoogle(value)
+		return googleuuid.NullUUID{UUID: id, Valid: err == nil}, err
+	})
+	web_request_readers.RegisterConverter(parseGofrs)
+	web_request_readers.RegisterConverter(func(value string) (gofrsuuid.NullUUID, error) {
+		id, err := parseGofrs(value)
+		return gofrsuuid.NullUUID{UUID: id, Valid: err == nil}, err
+	})
+}
+
+func parseGoogle(value string) (googleuuid.UUID, error) {
+	id, err := googleuuid.Parse(value)
+	if err != nil {
+		return googleuuid.Nil, web_request_readers.InvalidFormat{Format: format, Value: value, Err: err}
+	}
+	return id, nil
+}
+
+func parseGofrs(value string) (gofrsuuid.UUID, error) {
+	id, err := gofrsuuid.FromString(value)
+	if err != nil {
+		return gofrsuuid.Nil, web_request_readers.InvalidFormat{Format: format, Value: value, Err: err}
+	}
+	return id, nil
+}
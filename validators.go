@@ -0,0 +1,54 @@
+package web_request_readers
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A Validator checks a field's value after it has been set from a
+// request, returning an error if the value isn't acceptable.
+type Validator func(value interface{}) error
+
+var validators = make(map[string]Validator)
+
+// RegisterValidator registers a validator for the validate tag
+// option.  Once registered, a field tagged with
+//
+//	request:"email,validate=email"
+//
+// will have its value passed to the validator after it is set.  The
+// option may be repeated to run several validators, in order.  A
+// failing validator is reported as a FieldError whose Code is the
+// validator's name, so FieldMessages can supply a message for it with
+// e.g. "email.email".
+//
+// Pointer fields are validated using the value they point to, and nil
+// pointers are not validated.
+func RegisterValidator(name string, validator Validator) {
+	validators[name] = validator
+}
+
+// runValidators runs the validators named by a field's validate
+// options, returning the name of the first one that fails.
+func runValidators(field reflect.Value, args []string) (string, error) {
+	names := tagOptions(args, "validate")
+	if len(names) == 0 {
+		return "", nil
+	}
+	for field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return "", nil
+		}
+		field = field.Elem()
+	}
+	for _, name := range names {
+		validator, ok := validators[name]
+		if !ok {
+			return name, fmt.Errorf("No validator registered for %s", name)
+		}
+		if err := validator(field.Interface()); err != nil {
+			return name, err
+		}
+	}
+	return "", nil
+}